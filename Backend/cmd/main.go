@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,9 +17,14 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/user/Lotterich/internal/draws"
 	"github.com/user/Lotterich/internal/handlers"
+	"github.com/user/Lotterich/internal/jobs"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/prize"
 	"github.com/user/Lotterich/internal/repositories"
 	"github.com/user/Lotterich/internal/routes"
+	"github.com/user/Lotterich/internal/throttle"
 )
 
 func main() {
@@ -30,8 +37,7 @@ func main() {
 	requiredEnvVars := []string{
 		"MONGO_URI",
 		"DB_NAME",
-		"TELEGRAM_BOT_TOKEN",
-		"TELEGRAM_CHAT_ID",
+		"SMTP_HOST",
 	}
 
 	for _, envVar := range requiredEnvVars {
@@ -61,9 +67,76 @@ func main() {
 	collectionRepo := repositories.NewCollectionRepository(db)
 	statisticsRepo := repositories.NewStatisticsRepository(db)
 	otpRepo := repositories.NewOTPRepository(db)
+	recheckJobRepo := repositories.NewRecheckJobRepository(db)
+	drawExceptionRepo := repositories.NewDrawExceptionRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	auditRepo := repositories.NewAuditLogRepository(db)
+	statisticsRevisionRepo := repositories.NewStatisticsRevisionRepository(db)
+	preferencesRepo := repositories.NewPreferencesRepository(db)
+	notifyJobRepo := repositories.NewNotifyJobRepository(db)
+
+	// Load prize rule sets (ใช้กติกาที่ฝังมากับโปรแกรมถ้าไม่ได้กำหนด PRIZE_RULES_FILE)
+	prizeEngine := prize.Default()
+	if path := os.Getenv("PRIZE_RULES_FILE"); path != "" {
+		prizeEngine, err = prize.LoadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to load prize rules from %s: %v", path, err)
+		}
+		log.Printf("Loaded prize rules from: %s", path)
+	}
+
+	// Draw schedule (งวดวันที่ 1 และ 16 ยกเว้นวันที่ admin กำหนด)
+	drawSchedule := draws.NewSchedule(drawExceptionRepo)
+
+	// Brute-force protection (ATTEMPT_STORE=memory เก็บตัวนับในหน่วยความจำแทน MongoDB)
+	var attemptStore throttle.Store
+	if getEnv("ATTEMPT_STORE", "mongo") == "memory" {
+		attemptStore = throttle.NewMemoryStore()
+	} else {
+		attemptStore = repositories.NewLoginAttemptRepository(db)
+	}
+	loginGuard := throttle.NewGuard("login", attemptStore,
+		throttle.Policy{Free: 5, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute, Window: 15 * time.Minute},
+		throttle.Policy{Free: 20, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour},
+	)
+	otpGuard := throttle.NewGuard("otp", attemptStore,
+		throttle.Policy{Free: 3, BaseDelay: time.Minute, MaxDelay: 30 * time.Minute, Window: 30 * time.Minute},
+		throttle.Policy{Free: 10, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour},
+	)
+	resendGuard := throttle.NewGuard("verify-email", attemptStore,
+		throttle.Policy{Free: 1, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour},
+		throttle.Policy{Free: 10, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour},
+	)
+
+	// Notification channels: email for messages to one user, and every
+	// configured chat/webhook channel for announcements. Handlers only queue
+	// messages in the outbox; its worker delivers them with retries
+	channels, announce := setupNotifiers()
+	outboxRepo := repositories.NewOutboxRepository(db)
+	outbox := jobs.NewOutbox(outboxRepo, channels...)
+	outbox.Start()
+	mailer := outbox.Channel("email")
+	queued := make([]notify.Notifier, len(announce))
+	for i, name := range announce {
+		queued[i] = outbox.Channel(name)
+	}
+	announcer := notify.NewDispatcher(queued...)
+
+	// Background jobs
+	userNotifier := jobs.NewUserNotifier(preferencesRepo, userRepo, outbox)
+	subscriberNotifier := jobs.NewSubscriberNotifier(notifyJobRepo, preferencesRepo, userRepo, userNotifier)
+	weeklyDigest := jobs.NewWeeklyDigest(subscriberNotifier, statisticsRepo, collectionRepo, drawSchedule)
+	winNotifier := jobs.NewWinNotifier(userRepo, collectionRepo, userNotifier)
+	prizeRechecker := jobs.NewPrizeRechecker(collectionRepo, statisticsRepo, recheckJobRepo, prizeEngine, winNotifier)
 
 	// Create Gin router
 	router := gin.Default()
+	// ไม่เชื่อ X-Forwarded-For เว้นแต่มาจาก proxy ที่ตั้งค่าไว้ ไม่งั้น c.ClientIP() ปลอมได้
+	// และข้ามการจำกัดจำนวนครั้งต่อ IP ได้
+	if err := router.SetTrustedProxies(splitEnv("TRUSTED_PROXIES")); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Health check route for Render
 	router.GET("/", func(c *gin.Context) {
@@ -84,12 +157,33 @@ func main() {
 	}))
 
 	// Create handlers
-	authHandler := handlers.NewAuthHandler(userRepo, collectionRepo, otpRepo)
-	collectionHandler := handlers.NewCollectionHandler(collectionRepo, statisticsRepo)
-	statisticsHandler := handlers.NewStatisticsHandler(statisticsRepo, collectionRepo)
+	authHandler := handlers.NewAuthHandler(userRepo, collectionRepo, otpRepo, refreshTokenRepo, sessionRepo, auditRepo, preferencesRepo, mailer, userNotifier, loginGuard, otpGuard, resendGuard)
+	collectionHandler := handlers.NewCollectionHandler(collectionRepo, statisticsRepo, prizeEngine, drawSchedule)
+	statisticsHandler := handlers.NewStatisticsHandler(statisticsRepo, collectionRepo, prizeRechecker, auditRepo, statisticsRevisionRepo, announcer, subscriberNotifier)
+	drawHandler := handlers.NewDrawHandler(drawSchedule, drawExceptionRepo, auditRepo)
+	adminUserHandler := handlers.NewAdminUserHandler(userRepo, collectionRepo, auditRepo, authHandler)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+	outboxHandler := handlers.NewOutboxHandler(outboxRepo, outbox, auditRepo)
+	var bot notify.Notifier
+	if outbox.Has("telegram") {
+		bot = outbox.Channel("telegram")
+	}
+	telegramHandler := handlers.NewTelegramHandler(userRepo, otpRepo, preferencesRepo, statisticsRepo, collectionRepo, prizeEngine, bot, os.Getenv("TELEGRAM_BOT_USERNAME"), os.Getenv("TELEGRAM_WEBHOOK_SECRET"))
+	var lineBot notify.Notifier
+	if outbox.Has("line") {
+		lineBot = outbox.Channel("line")
+	}
+	lineHandler := handlers.NewLineHandler(userRepo, otpRepo, preferencesRepo, lineBot, os.Getenv("LINE_BOT_ID"), os.Getenv("LINE_CHANNEL_SECRET"))
+	preferencesHandler := handlers.NewPreferencesHandler(preferencesRepo, userRepo)
+
+	// ทำงานแจ้งผู้ที่สมัครรับที่ค้างจากครั้งก่อนต่อ (หลังจาก handler ลงทะเบียนวิธีสร้างข้อความแล้ว)
+	if err := subscriberNotifier.Resume(context.Background()); err != nil {
+		log.Printf("Failed to resume notify jobs: %v", err)
+	}
+	weeklyDigest.Start()
 
 	// Setup routes
-	routes.SetupRoutes(router, authHandler, collectionHandler, statisticsHandler)
+	routes.SetupRoutes(router, sessionRepo, authHandler, collectionHandler, statisticsHandler, drawHandler, adminUserHandler, auditHandler, outboxHandler, telegramHandler, lineHandler, preferencesHandler)
 
 	// Start server
 	port := getEnv("PORT", "8080")
@@ -97,6 +191,53 @@ func main() {
 
 	// Graceful shutdown
 	gracefulShutdown(srv)
+
+	// Let running recheck jobs and deliveries finish before disconnecting from MongoDB.
+	// Subscriber jobs stop after their current batch and resume on the next start
+	prizeRechecker.Wait()
+	weeklyDigest.Stop()
+	subscriberNotifier.Stop()
+	outbox.Stop()
+}
+
+// setupNotifiers builds every configured notification channel from the
+// environment, and returns it with the names of the channels that announce
+// new draws. Email is always on; the others are enabled by their variables
+func setupNotifiers() (channels []notify.Notifier, announce []string) {
+	channels = append(channels, notify.NewSMTP(notify.SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     getEnvInt("SMTP_PORT", 587),
+		Username: os.Getenv("SMTP_USER"),
+		Password: os.Getenv("SMTP_PASS"),
+		From:     os.Getenv("SMTP_FROM"),
+	}))
+
+	// Telegram and LINE also send personal messages to linked chats and LINE
+	// accounts, so they only need a default recipient to announce draws
+	if token := os.Getenv("TELEGRAM_BOT_TOKEN"); token != "" {
+		chatID := os.Getenv("TELEGRAM_CHAT_ID")
+		channels = append(channels, notify.NewTelegram(token, chatID))
+		if chatID != "" {
+			announce = append(announce, "telegram")
+		}
+	}
+	if token := os.Getenv("LINE_CHANNEL_TOKEN"); token != "" {
+		to := os.Getenv("LINE_TO")
+		channels = append(channels, notify.NewLINE(token, to))
+		if to != "" {
+			announce = append(announce, "line")
+		}
+	}
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		channels = append(channels, notify.NewWebhook(url, os.Getenv("NOTIFY_WEBHOOK_SECRET")))
+		announce = append(announce, "webhook")
+	}
+	if len(announce) == 0 {
+		log.Println("Warning: no announcement channel is configured (Telegram, LINE or webhook)")
+	} else {
+		log.Printf("Announcement channels: %v", announce)
+	}
+	return channels, announce
 }
 
 func connectToMongoDB(uri string) (*mongo.Client, error) {
@@ -168,3 +309,22 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// splitEnv reads a comma-separated list; it returns nil when key is unset
+func splitEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,194 @@
+// Package analytics คำนวณสถิติความถี่ของเลขที่ออกรางวัลจากผลรางวัลย้อนหลัง
+package analytics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/user/Lotterich/internal/models"
+)
+
+// NumberStat คือสถิติของเลขหนึ่งตัวภายในช่วงงวดที่วิเคราะห์
+type NumberStat struct {
+	Number        string  `json:"number"`
+	Count         int     `json:"count"`         // จำนวนงวดที่ออก
+	Percent       float64 `json:"percent"`       // ร้อยละของงวดทั้งหมดในช่วง
+	LastSeen      string  `json:"lastSeen"`      // งวดล่าสุดที่ออก
+	Gap           *int    `json:"gap"`           // จำนวนงวดนับจากครั้งล่าสุดที่ออก (0 = งวดล่าสุด, null = ไม่เคยออกในช่วงนี้)
+	LongestStreak int     `json:"longestStreak"` // จำนวนงวดติดต่อกันที่ออกมากที่สุด
+}
+
+// Table คือตารางความถี่ของเลขประเภทหนึ่ง (เช่น สองตัวท้าย)
+type Table struct {
+	Frequencies []NumberStat `json:"frequencies"` // เฉพาะเลขที่เคยออก เรียงจากออกบ่อยไปน้อย
+	Hot         []NumberStat `json:"hot"`         // ออกบ่อยที่สุด (เสมอกันให้งวดล่าสุดมาก่อน)
+	Cold        []NumberStat `json:"cold"`        // ออกน้อยที่สุดรวมเลขที่ไม่เคยออก (เสมอกันให้ห่างนานกว่ามาก่อน)
+	Streaks     []NumberStat `json:"streaks"`     // ออกติดต่อกันนานที่สุด
+}
+
+// PositionTable คือความถี่ของเลขโดด 0-9 ในหลักหนึ่งของรางวัลที่ 1
+type PositionTable struct {
+	Position int   `json:"position"` // 1 = หลักแสน ... 6 = หลักหน่วย
+	Table    Table `json:"table"`
+}
+
+// Report คือผลการวิเคราะห์ของช่วงงวดที่เลือก
+type Report struct {
+	Draws        int             `json:"draws"`
+	From         string          `json:"from,omitempty"`
+	To           string          `json:"to,omitempty"`
+	Last2        Table           `json:"last2"`
+	Last3        Table           `json:"last3"`
+	First3       Table           `json:"first3"`
+	Prize1Digits []PositionTable `json:"prize1Digits"`
+}
+
+// Analyze วิเคราะห์ผลรางวัล draws ที่เรียงจากงวดล่าสุดไปเก่าสุด
+// top คือจำนวนอันดับใน hot, cold และ streaks
+func Analyze(draws []models.Statistics, top int) Report {
+	report := Report{Draws: len(draws)}
+	if len(draws) > 0 {
+		report.From = draws[len(draws)-1].Date
+		report.To = draws[0].Date
+	}
+
+	report.Last2 = buildTable(draws, 2, top, func(s models.Statistics) []string {
+		return []string{s.Last2}
+	})
+	report.Last3 = buildTable(draws, 3, top, func(s models.Statistics) []string {
+		return []string{s.Last3One, s.Last3Two}
+	})
+	report.First3 = buildTable(draws, 3, top, func(s models.Statistics) []string {
+		return []string{s.First3One, s.First3Two}
+	})
+	for pos := 0; pos < 6; pos++ {
+		pos := pos
+		report.Prize1Digits = append(report.Prize1Digits, PositionTable{
+			Position: pos + 1,
+			Table: buildTable(draws, 1, top, func(s models.Statistics) []string {
+				if len(s.Prize1) != 6 {
+					return nil
+				}
+				return []string{s.Prize1[pos : pos+1]}
+			}),
+		})
+	}
+	return report
+}
+
+// buildTable นับเลขขนาด digits หลักที่ numbersOf ดึงออกมาจากแต่ละงวด
+// งวดหนึ่งนับเลขแต่ละตัวครั้งเดียว แม้จะออกซ้ำสองรางวัลในงวดเดียวกัน
+func buildTable(draws []models.Statistics, digits, top int, numbersOf func(models.Statistics) []string) Table {
+	type tracker struct {
+		stat   NumberStat
+		streak int
+		last   int // index ของงวดล่าสุดที่ออก (งวดเรียงจากใหม่ไปเก่า)
+	}
+	trackers := map[string]*tracker{}
+
+	for i, draw := range draws {
+		seen := map[string]bool{}
+		for _, number := range numbersOf(draw) {
+			if len(number) != digits || seen[number] {
+				continue
+			}
+			seen[number] = true
+
+			t, ok := trackers[number]
+			if !ok {
+				gap := i
+				t = &tracker{stat: NumberStat{Number: number, LastSeen: draw.Date, Gap: &gap}, last: -2}
+				trackers[number] = t
+			}
+			t.stat.Count++
+			if t.last == i-1 {
+				t.streak++
+			} else {
+				t.streak = 1
+			}
+			t.last = i
+			if t.streak > t.stat.LongestStreak {
+				t.stat.LongestStreak = t.streak
+			}
+		}
+	}
+
+	// เลขทุกตัวที่เป็นไปได้ เพื่อให้อันดับ cold รวมเลขที่ไม่เคยออกด้วย
+	all := make([]NumberStat, 0, pow10(digits))
+	for n := 0; n < pow10(digits); n++ {
+		number := fmt.Sprintf("%0*d", digits, n)
+		stat := NumberStat{Number: number}
+		if t, ok := trackers[number]; ok {
+			stat = t.stat
+		}
+		if len(draws) > 0 {
+			stat.Percent = float64(stat.Count) / float64(len(draws)) * 100
+		}
+		all = append(all, stat)
+	}
+
+	table := Table{}
+	sort.SliceStable(all, func(i, j int) bool { return hotter(all[i], all[j]) })
+	for _, stat := range all {
+		if stat.Count > 0 {
+			table.Frequencies = append(table.Frequencies, stat)
+		}
+	}
+	if table.Frequencies == nil {
+		table.Frequencies = []NumberStat{}
+	}
+	table.Hot = firstN(table.Frequencies, top)
+
+	cold := append([]NumberStat(nil), all...)
+	sort.SliceStable(cold, func(i, j int) bool {
+		if cold[i].Count != cold[j].Count {
+			return cold[i].Count < cold[j].Count
+		}
+		return gapOf(cold[i], len(draws)) > gapOf(cold[j], len(draws))
+	})
+	table.Cold = firstN(cold, top)
+
+	streaks := append([]NumberStat(nil), table.Frequencies...)
+	sort.SliceStable(streaks, func(i, j int) bool {
+		if streaks[i].LongestStreak != streaks[j].LongestStreak {
+			return streaks[i].LongestStreak > streaks[j].LongestStreak
+		}
+		return hotter(streaks[i], streaks[j])
+	})
+	table.Streaks = firstN(streaks, top)
+	return table
+}
+
+// hotter เรียงตามจำนวนครั้งที่ออกมากไปน้อย ถ้าเท่ากันให้เลขที่ออกล่าสุดมาก่อน
+func hotter(a, b NumberStat) bool {
+	if a.Count != b.Count {
+		return a.Count > b.Count
+	}
+	if a.LastSeen != b.LastSeen {
+		return a.LastSeen > b.LastSeen
+	}
+	return a.Number < b.Number
+}
+
+// gapOf คืนระยะห่าง โดยถือว่าเลขที่ไม่เคยออกห่างเท่ากับจำนวนงวดทั้งหมด
+func gapOf(s NumberStat, draws int) int {
+	if s.Gap == nil {
+		return draws
+	}
+	return *s.Gap
+}
+
+func firstN(stats []NumberStat, n int) []NumberStat {
+	if n > len(stats) {
+		n = len(stats)
+	}
+	return append([]NumberStat{}, stats[:n]...)
+}
+
+func pow10(n int) int {
+	p := 1
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
@@ -0,0 +1,106 @@
+// Package draws คำนวณวันออกรางวัลสลากกินแบ่งรัฐบาล
+// งวดปกติออกวันที่ 1 และ 16 ของทุกเดือน ยกเว้นวันที่ผู้ดูแลระบบกำหนดให้เลื่อนหรืองด
+package draws
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+const dateLayout = "2006-01-02"
+
+// maxShift คือระยะที่งวดหนึ่งถูกเลื่อนได้มากที่สุด ใช้ขยายช่วงค้นหางวดปกติที่อาจถูกเลื่อนเข้ามาในช่วงที่ขอ
+const maxShift = 31 * 24 * time.Hour
+
+// Location คือเขตเวลาประเทศไทย ใช้ตัดสินว่า "วันนี้" คือวันไหน
+var Location = time.FixedZone("ICT", 7*60*60)
+
+// ErrNoUpcomingDraw ถูกคืนเมื่อไม่พบงวดถัดไปภายในหนึ่งปี (เช่น งดออกรางวัลทั้งหมด)
+var ErrNoUpcomingDraw = errors.New("no upcoming draw")
+
+// Schedule คำนวณปฏิทินวันออกรางวัลโดยใช้รายการวันยกเว้นจากฐานข้อมูล
+type Schedule struct {
+	exceptionRepo *repositories.DrawExceptionRepository
+}
+
+func NewSchedule(exceptionRepo *repositories.DrawExceptionRepository) *Schedule {
+	return &Schedule{exceptionRepo: exceptionRepo}
+}
+
+// Calendar คืนงวดที่ออกรางวัลจริงระหว่าง from ถึง to (นับรวมทั้งสองวัน) เรียงตามวันที่
+func (s *Schedule) Calendar(ctx context.Context, from, to time.Time) ([]models.Draw, error) {
+	exceptions, err := s.exceptionRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Calendar(from, to, exceptions), nil
+}
+
+// Next คืนงวดแรกที่ออกรางวัลในวันเดียวกับ at หรือหลังจากนั้น
+func (s *Schedule) Next(ctx context.Context, at time.Time) (*models.Draw, error) {
+	calendar, err := s.Calendar(ctx, at, at.AddDate(1, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+	if len(calendar) == 0 {
+		return nil, ErrNoUpcomingDraw
+	}
+	return &calendar[0], nil
+}
+
+// Calendar คำนวณงวดระหว่าง from ถึง to จากงวดปกติและวันยกเว้น
+func Calendar(from, to time.Time, exceptions []models.DrawException) []models.Draw {
+	start := dateOf(from)
+	end := dateOf(to)
+
+	byDate := make(map[string]models.DrawException, len(exceptions))
+	for _, e := range exceptions {
+		byDate[e.Date] = e
+	}
+
+	draws := []models.Draw{}
+	for _, regular := range regularDates(start.Add(-maxShift), end.Add(maxShift)) {
+		draw := models.Draw{Date: regular, RegularDate: regular}
+		if e, ok := byDate[regular]; ok {
+			if e.MovedTo == "" {
+				continue // งดออกรางวัล
+			}
+			draw.Date = e.MovedTo
+			draw.Moved = e.MovedTo != regular
+			draw.Note = e.Note
+		}
+		if draw.Date < start.Format(dateLayout) || draw.Date > end.Format(dateLayout) {
+			continue
+		}
+		draws = append(draws, draw)
+	}
+	sort.Slice(draws, func(i, j int) bool { return draws[i].Date < draws[j].Date })
+	return draws
+}
+
+// regularDates คืนวันที่ 1 และ 16 ทุกเดือนระหว่าง from ถึง to
+func regularDates(from, to time.Time) []string {
+	var dates []string
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, Location)
+	for !month.After(to) {
+		for _, day := range []int{1, 16} {
+			d := time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, Location)
+			if !d.Before(from) && !d.After(to) {
+				dates = append(dates, d.Format(dateLayout))
+			}
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	return dates
+}
+
+// dateOf ตัดเวลาออกให้เหลือวันที่ตามเวลาประเทศไทย
+func dateOf(t time.Time) time.Time {
+	t = t.In(Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Location)
+}
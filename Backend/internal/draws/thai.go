@@ -0,0 +1,31 @@
+package draws
+
+import (
+	"fmt"
+	"time"
+)
+
+var thaiMonths = []string{
+	"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+	"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม",
+}
+
+// ThaiDate แปลงวันที่ YYYY-MM-DD เป็นรูปแบบไทย เช่น "16 ตุลาคม 2569"
+// ถ้ารูปแบบไม่ถูกต้องจะคืนค่าเดิม
+func ThaiDate(date string) string {
+	t, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return date
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), thaiMonths[t.Month()-1], t.Year()+543)
+}
+
+// EnglishDate formats a YYYY-MM-DD date as e.g. "16 October 2026"
+// and returns it unchanged if it doesn't parse
+func EnglishDate(date string) string {
+	t, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return date
+	}
+	return t.Format("2 January 2006")
+}
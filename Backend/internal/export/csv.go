@@ -0,0 +1,44 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	return c.w.Write(columns)
+}
+
+// WriteRow เขียนทีละแถวและ flush ทันที เพื่อให้ข้อมูลถูกส่งออกไปแบบ stream
+func (c *csvWriter) WriteRow(values []string) error {
+	if err := c.w.Write(values); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) WriteSummary(rows [][2]string) error {
+	if err := c.w.Write(nil); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := c.w.Write(row[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
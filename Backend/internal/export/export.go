@@ -0,0 +1,53 @@
+// Package export เขียนข้อมูลแบบตารางออกเป็นไฟล์ CSV, XLSX หรือ PDF
+package export
+
+import (
+	"errors"
+	"io"
+)
+
+// รูปแบบไฟล์ที่รองรับ
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// ErrUnsupportedFormat ถูกคืนเมื่อขอรูปแบบไฟล์ที่ไม่รองรับ
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Writer เขียนตารางทีละแถวแล้วปิดท้ายด้วยแถวสรุป
+// ต้องเรียก Close เสมอเพื่อให้ข้อมูลถูกเขียนลง io.Writer ครบ
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []string) error
+	// WriteSummary เขียนแถวสรุป (label, value) ต่อท้ายตาราง
+	WriteSummary(rows [][2]string) error
+	Close() error
+}
+
+// NewWriter สร้าง Writer ตามรูปแบบไฟล์ title ใช้เป็นชื่อแผ่นงานหรือหัวเรื่องของเอกสาร
+func NewWriter(format string, w io.Writer, title string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, title)
+	case FormatPDF:
+		return newPDFWriter(w, title), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ContentType คืน MIME type ของรูปแบบไฟล์
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
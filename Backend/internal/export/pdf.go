@@ -0,0 +1,177 @@
+package export
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pdfMargin     = 10.0
+	pdfRowHeight  = 6.0
+	pdfLineHeight = 4.5
+	// pdfMaxColumnWidth (มม.) จำกัดความกว้างของคอลัมน์ที่ข้อความยาว (เช่นเลขรางวัลที่ 5 ทั้ง 100 เลข)
+	// ข้อความที่ยาวกว่านี้จะถูกตัดขึ้นบรรทัดใหม่ในช่องเดียวกัน
+	pdfMaxColumnWidth = 60.0
+)
+
+// pdfWriter เขียนตารางเป็นเอกสาร PDF แนวนอนสำหรับพิมพ์
+// ใช้ฟอนต์มาตรฐานของ PDF จึงควรใช้หัวตารางภาษาอังกฤษ
+type pdfWriter struct {
+	out     io.Writer
+	pdf     *gofpdf.Fpdf
+	title   string
+	columns []string
+	widths  []float64
+	// tableTop คือตำแหน่งแถวแรกใต้หัวตารางของแต่ละหน้า
+	tableTop float64
+}
+
+func newPDFWriter(w io.Writer, title string) *pdfWriter {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	p := &pdfWriter{out: w, pdf: pdf, title: title}
+	pdf.SetHeaderFunc(p.header)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, "Page "+strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	return p
+}
+
+// WriteHeader เก็บหัวตารางไว้ ความกว้างคอลัมน์คำนวณตอนเขียนแถวแรก
+func (p *pdfWriter) WriteHeader(columns []string) error {
+	p.columns = columns
+	return p.pdf.Error()
+}
+
+// start แบ่งความกว้างหน้ากระดาษตามหัวตารางและค่าในแถวแรก แล้วเริ่มหน้าแรก
+// คอลัมน์ได้ความกว้างอย่างน้อยเท่าหัวตาราง ส่วนค่าที่ยาวจะได้ไม่เกิน pdfMaxColumnWidth ก่อนปรับให้เต็มหน้า
+func (p *pdfWriter) start(first []string) {
+	margin := 2 * p.pdf.GetCellMargin()
+	minimum := make([]float64, len(p.columns))
+	wanted := make([]float64, len(p.columns))
+	p.pdf.SetFont("Helvetica", "B", 9)
+	for i, col := range p.columns {
+		minimum[i] = p.pdf.GetStringWidth(col) + margin
+		wanted[i] = minimum[i]
+	}
+	p.pdf.SetFont("Helvetica", "", 9)
+	for i := range wanted {
+		if i < len(first) {
+			wanted[i] = max(wanted[i], min(p.pdf.GetStringWidth(first[i])+margin, pdfMaxColumnWidth))
+		}
+	}
+
+	pageWidth, _ := p.pdf.GetPageSize()
+	usable := pageWidth - 2*pdfMargin
+	total, extra := 0.0, 0.0
+	for i := range wanted {
+		total += wanted[i]
+		extra += wanted[i] - minimum[i]
+	}
+	p.widths = make([]float64, len(p.columns))
+	for i := range p.widths {
+		switch {
+		case total <= usable:
+			p.widths[i] = wanted[i] * usable / total
+		case extra > 0 && total-extra <= usable:
+			// ลดเฉพาะส่วนที่เกินความกว้างของหัวตาราง
+			p.widths[i] = minimum[i] + (wanted[i]-minimum[i])*(usable-(total-extra))/extra
+		default:
+			p.widths[i] = usable / float64(len(p.widths))
+		}
+	}
+	p.pdf.AddPage()
+}
+
+// WriteRow ตัดข้อความที่ยาวเกินช่องขึ้นบรรทัดใหม่ แถวที่สูงเกินพื้นที่ที่เหลือจะพิมพ์ต่อในหน้าถัดไป
+func (p *pdfWriter) WriteRow(values []string) error {
+	if p.pdf.PageNo() == 0 {
+		p.start(values)
+	}
+	p.pdf.SetFont("Helvetica", "", 9)
+
+	cells := make([][][]byte, len(p.widths))
+	lines := 1
+	for i := range p.widths {
+		if i < len(values) {
+			cells[i] = p.pdf.SplitLines([]byte(values[i]), p.widths[i])
+		}
+		lines = max(lines, len(cells[i]))
+	}
+
+	_, pageHeight := p.pdf.GetPageSize()
+	padding := pdfRowHeight - pdfLineHeight
+	for from := 0; from < lines; {
+		// จำนวนบรรทัดที่ยังพอในหน้านี้
+		fit := int((pageHeight - 2*pdfMargin - p.pdf.GetY() - padding) / pdfLineHeight)
+		if fit <= 0 || (from == 0 && fit < lines && p.pdf.GetY() > p.tableTop) {
+			// ขึ้นหน้าใหม่ก่อน ถ้าแถวนี้ไม่พอในหน้าที่มีแถวอื่นอยู่แล้ว
+			p.pdf.AddPage()
+			continue
+		}
+		to := min(lines, from+fit)
+		p.drawRowPart(cells, from, to, padding)
+		from = to
+	}
+	return p.pdf.Error()
+}
+
+// drawRowPart วาดบรรทัด from ถึง to ของทุกช่องในแถว
+func (p *pdfWriter) drawRowPart(cells [][][]byte, from, to int, padding float64) {
+	x, y := pdfMargin, p.pdf.GetY()
+	height := float64(to-from)*pdfLineHeight + padding
+	for i, width := range p.widths {
+		p.pdf.Rect(x, y, width, height, "D")
+		for j := from; j < to && j < len(cells[i]); j++ {
+			p.pdf.SetXY(x, y+padding/2+float64(j-from)*pdfLineHeight)
+			p.pdf.CellFormat(width, pdfLineHeight, string(cells[i][j]), "", 0, "L", false, 0, "")
+		}
+		x += width
+	}
+	p.pdf.SetXY(pdfMargin, y+height)
+}
+
+func (p *pdfWriter) WriteSummary(rows [][2]string) error {
+	if p.pdf.PageNo() == 0 {
+		p.start(nil)
+	}
+	p.pdf.Ln(4)
+	for _, row := range rows {
+		p.pdf.SetFont("Helvetica", "B", 10)
+		p.pdf.CellFormat(50, pdfRowHeight, row[0], "", 0, "L", false, 0, "")
+		p.pdf.SetFont("Helvetica", "", 10)
+		p.pdf.CellFormat(50, pdfRowHeight, row[1], "", 1, "L", false, 0, "")
+	}
+	return p.pdf.Error()
+}
+
+func (p *pdfWriter) Close() error {
+	if p.pdf.PageNo() == 0 {
+		p.start(nil)
+	}
+	return p.pdf.Output(p.out)
+}
+
+// header พิมพ์หัวเรื่องและหัวตารางซ้ำทุกหน้า
+func (p *pdfWriter) header() {
+	p.pdf.SetFont("Helvetica", "B", 14)
+	p.pdf.CellFormat(0, 8, p.title, "", 1, "L", false, 0, "")
+	p.pdf.SetFont("Helvetica", "", 8)
+	p.pdf.CellFormat(0, 5, "Generated "+time.Now().Format("2006-01-02 15:04"), "", 1, "L", false, 0, "")
+	p.pdf.Ln(2)
+	p.pdf.SetFont("Helvetica", "B", 9)
+	p.pdf.SetFillColor(230, 230, 230)
+	for i, col := range p.columns {
+		p.pdf.CellFormat(p.widths[i], pdfRowHeight, col, "1", 0, "L", true, 0, "")
+	}
+	if len(p.columns) > 0 {
+		p.pdf.Ln(-1)
+	}
+	p.tableTop = p.pdf.GetY()
+}
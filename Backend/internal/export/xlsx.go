@@ -0,0 +1,87 @@
+package export
+
+import (
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+	bold   int
+}
+
+func newXLSXWriter(w io.Writer, title string) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	sheet := sheetName(title)
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{out: w, file: f, stream: stream, row: 1, bold: bold}, nil
+}
+
+func (x *xlsxWriter) WriteHeader(columns []string) error {
+	cells := make([]interface{}, len(columns))
+	for i, col := range columns {
+		cells[i] = excelize.Cell{StyleID: x.bold, Value: col}
+	}
+	return x.writeCells(cells)
+}
+
+func (x *xlsxWriter) WriteRow(values []string) error {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		cells[i] = v
+	}
+	return x.writeCells(cells)
+}
+
+func (x *xlsxWriter) WriteSummary(rows [][2]string) error {
+	x.row++ // เว้นหนึ่งแถว
+	for _, row := range rows {
+		if err := x.writeCells([]interface{}{excelize.Cell{StyleID: x.bold, Value: row[0]}, row[1]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
+
+func (x *xlsxWriter) writeCells(cells []interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	x.row++
+	return x.stream.SetRow(cell, cells)
+}
+
+// sheetName ตัดชื่อแผ่นงานให้ไม่เกิน 31 ตัวอักษรตามข้อจำกัดของ Excel
+func sheetName(title string) string {
+	runes := []rune(title)
+	if len(runes) == 0 {
+		return "Sheet1"
+	}
+	if len(runes) > 31 {
+		runes = runes[:31]
+	}
+	return string(runes)
+}
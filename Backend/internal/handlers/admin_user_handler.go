@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
+// AdminUserHandler handles the admin user management API
+type AdminUserHandler struct {
+	userRepo       *repositories.UserRepository
+	collectionRepo *repositories.CollectionRepository
+	auditRepo      *repositories.AuditLogRepository
+	auth           *AuthHandler
+}
+
+// NewAdminUserHandler creates a new AdminUserHandler
+// It uses auth to sign users out and to send password reset codes
+func NewAdminUserHandler(userRepo *repositories.UserRepository, collectionRepo *repositories.CollectionRepository, auditRepo *repositories.AuditLogRepository, auth *AuthHandler) *AdminUserHandler {
+	return &AdminUserHandler{
+		userRepo:       userRepo,
+		collectionRepo: collectionRepo,
+		auditRepo:      auditRepo,
+		auth:           auth,
+	}
+}
+
+// GetUsers lists users with search and pagination
+// GET /api/admin/users?search=&role=&suspended=&page=&limit=
+func (h *AdminUserHandler) GetUsers(c *gin.Context) {
+	search := models.UserSearch{
+		Search: c.Query("search"),
+		Role:   c.Query("role"),
+	}
+	if search.Role != "" && !models.IsValidRole(search.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role ไม่ถูกต้อง"})
+		return
+	}
+	if value := c.Query("suspended"); value != "" {
+		suspended, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "suspended ต้องเป็น true หรือ false"})
+			return
+		}
+		search.Suspended = &suspended
+	}
+
+	var err error
+	if search.Page, err = positiveIntQuery(c, "page", 1, 1<<20); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if search.Limit, err = positiveIntQuery(c, "limit", defaultUserPageSize, maxUserPageSize); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, total, err := h.userRepo.Search(search)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	emails := make([]string, len(users))
+	for i, user := range users {
+		emails[i] = user.Email
+	}
+	counts, err := h.collectionRepo.CountByEmails(c.Request.Context(), emails)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count tickets"})
+		return
+	}
+
+	views := make([]models.AdminUserView, len(users))
+	for i := range users {
+		views[i] = users[i].ToAdminView(counts[users[i].Email])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": views,
+		"page":  search.Page,
+		"limit": search.Limit,
+		"total": total,
+	})
+}
+
+// GetUser returns one user with their ticket count
+// GET /api/admin/users/:id
+func (h *AdminUserHandler) GetUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": h.view(c, user)})
+}
+
+// ChangeRole promotes or demotes a user
+// PATCH /api/admin/users/:id/role
+func (h *AdminUserHandler) ChangeRole(c *gin.Context) {
+	var input models.ChangeRoleRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.IsValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role ไม่ถูกต้อง"})
+		return
+	}
+
+	user, ok := h.findUser(c)
+	if !ok || !h.notSelf(c, user) {
+		return
+	}
+	if user.Role == input.Role {
+		c.JSON(http.StatusOK, gin.H{"user": h.view(c, user)})
+		return
+	}
+
+	// Never remove the last admin
+	if user.Role == models.RoleAdmin {
+		admins, err := h.userRepo.CountByRole(models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admins"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "ต้องมี Admin อย่างน้อย 1 คน"})
+			return
+		}
+	}
+
+	if err := h.userRepo.SetRole(user.ID.Hex(), input.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+	// Tokens carry the role, so make the user log in again
+	h.auth.revokeAllLogins(c.Request.Context(), user.ID.Hex())
+
+	recordAudit(c, h.auditRepo, models.AuditUserRoleChanged, models.AuditTargetUser, user.ID.Hex(), map[string]interface{}{
+		"email": user.Email,
+		"from":  user.Role,
+		"to":    input.Role,
+	})
+
+	user.Role = input.Role
+	c.JSON(http.StatusOK, gin.H{"user": h.view(c, user)})
+}
+
+// Suspend blocks a user from logging in and signs them out everywhere
+// POST /api/admin/users/:id/suspend
+func (h *AdminUserHandler) Suspend(c *gin.Context) {
+	var input models.SuspendUserRequest
+	// The body is optional
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.findUser(c)
+	if !ok || !h.notSelf(c, user) {
+		return
+	}
+
+	if err := h.userRepo.Suspend(user.ID.Hex(), input.Reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+	h.auth.revokeAllLogins(c.Request.Context(), user.ID.Hex())
+
+	recordAudit(c, h.auditRepo, models.AuditUserSuspended, models.AuditTargetUser, user.ID.Hex(), map[string]interface{}{
+		"email":  user.Email,
+		"reason": input.Reason,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "ระงับบัญชีเรียบร้อย"})
+}
+
+// Unsuspend lets a suspended user log in again
+// POST /api/admin/users/:id/unsuspend
+func (h *AdminUserHandler) Unsuspend(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.Unsuspend(user.ID.Hex()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsuspend user"})
+		return
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditUserUnsuspended, models.AuditTargetUser, user.ID.Hex(), map[string]interface{}{
+		"email": user.Email,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "ยกเลิกการระงับบัญชีเรียบร้อย"})
+}
+
+// ForcePasswordReset signs the user out, blocks login until a new password is
+// set and emails a password reset OTP
+// POST /api/admin/users/:id/force-password-reset
+func (h *AdminUserHandler) ForcePasswordReset(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.RequirePasswordReset(user.ID.Hex()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to require password reset"})
+		return
+	}
+	h.auth.revokeAllLogins(c.Request.Context(), user.ID.Hex())
+
+	emailSent := true
+	if err := h.auth.sendOTP(c.Request.Context(), user.Email, models.OTPPurposePasswordReset); err != nil {
+		fmt.Printf("Failed to send password reset OTP: %v\n", err)
+		emailSent = false
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditUserPasswordResetForced, models.AuditTargetUser, user.ID.Hex(), map[string]interface{}{
+		"email":     user.Email,
+		"emailSent": emailSent,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "บังคับรีเซ็ตรหัสผ่านเรียบร้อย",
+		"emailSent": emailSent,
+	})
+}
+
+// findUser loads the user from the :id parameter or writes a 404
+func (h *AdminUserHandler) findUser(c *gin.Context) (*models.User, bool) {
+	user, err := h.userRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบผู้ใช้งานนี้"})
+		return nil, false
+	}
+	return user, true
+}
+
+// notSelf stops admins from demoting or suspending themselves
+func (h *AdminUserHandler) notSelf(c *gin.Context, user *models.User) bool {
+	if user.ID.Hex() == c.GetString("userID") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถทำรายการนี้กับบัญชีของตัวเองได้"})
+		return false
+	}
+	return true
+}
+
+// view returns the admin view of a user including the ticket count
+func (h *AdminUserHandler) view(c *gin.Context, user *models.User) models.AdminUserView {
+	counts, err := h.collectionRepo.CountByEmails(c.Request.Context(), []string{user.Email})
+	if err != nil {
+		fmt.Printf("Failed to count tickets: %v\n", err)
+	}
+	return user.ToAdminView(counts[user.Email])
+}
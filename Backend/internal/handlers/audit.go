@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+// recordAudit appends an audit entry for the user of the current request
+// A failure is only logged: the action itself has already happened
+func recordAudit(c *gin.Context, repo *repositories.AuditLogRepository, action, targetType, targetID string, details map[string]interface{}) {
+	writeAudit(c, repo, models.AuditEntry{
+		ActorID:    c.GetString("userID"),
+		ActorEmail: c.GetString("userEmail"),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+	})
+}
+
+// recordChange is recordAudit for an action that changed a document: before
+// and after are the document on each side of it (nil when created or deleted)
+func recordChange(c *gin.Context, repo *repositories.AuditLogRepository, action, targetType, targetID string, before, after interface{}, details map[string]interface{}) {
+	writeAudit(c, repo, models.AuditEntry{
+		ActorID:    c.GetString("userID"),
+		ActorEmail: c.GetString("userEmail"),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		Changes:    diffFields(before, after),
+	})
+}
+
+// recordAuthEvent appends an audit entry for an auth event, where the account
+// is both actor and target. userID is empty when no account matched the email
+func recordAuthEvent(c *gin.Context, repo *repositories.AuditLogRepository, action, userID, email string, details map[string]interface{}) {
+	entry := models.AuditEntry{
+		ActorID:    userID,
+		ActorEmail: email,
+		Action:     action,
+		Details:    details,
+	}
+	if userID != "" {
+		entry.TargetType = models.AuditTargetUser
+		entry.TargetID = userID
+	}
+	writeAudit(c, repo, entry)
+}
+
+func writeAudit(c *gin.Context, repo *repositories.AuditLogRepository, entry models.AuditEntry) {
+	entry.IP = c.ClientIP()
+	if err := repo.Record(c.Request.Context(), &entry); err != nil {
+		fmt.Printf("Failed to record audit entry %s: %v\n", entry.Action, err)
+	}
+}
+
+// diffFields compares two documents field by field using their JSON form and
+// returns the fields that differ. The id is left out; it's the entry's TargetID
+func diffFields(before, after interface{}) map[string]models.AuditChange {
+	from, to := jsonFields(before), jsonFields(after)
+	changes := map[string]models.AuditChange{}
+	for key, value := range from {
+		if !reflect.DeepEqual(value, to[key]) {
+			changes[key] = models.AuditChange{From: value, To: to[key]}
+		}
+	}
+	for key, value := range to {
+		if _, seen := from[key]; !seen {
+			changes[key] = models.AuditChange{To: value}
+		}
+	}
+	delete(changes, "id")
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func jsonFields(doc interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if doc == nil || reflect.ValueOf(doc).IsZero() {
+		return fields
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fields
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		fmt.Printf("Failed to read fields for audit diff: %v\n", err)
+	}
+	return fields
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// AuditHandler serves the audit log to admins
+type AuditHandler struct {
+	auditRepo *repositories.AuditLogRepository
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(auditRepo *repositories.AuditLogRepository) *AuditHandler {
+	return &AuditHandler{auditRepo: auditRepo}
+}
+
+// GetAuditLog lists audit entries, newest first
+// GET /api/admin/audit-log?actorId=&actorEmail=&action=&targetType=&targetId=&from=&to=&page=&limit=
+// from and to are dates (YYYY-MM-DD, Thai time) and both are inclusive
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	search := models.AuditSearch{
+		ActorID:    c.Query("actorId"),
+		ActorEmail: c.Query("actorEmail"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		TargetID:   c.Query("targetId"),
+	}
+	if from := c.Query("from"); from != "" {
+		day, err := time.ParseInLocation("2006-01-02", from, draws.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ from ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+		search.From = day
+	}
+	if to := c.Query("to"); to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, draws.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ to ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+		search.To = day.AddDate(0, 0, 1)
+	}
+
+	var err error
+	if search.Page, err = positiveIntQuery(c, "page", 1, 1<<20); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if search.Limit, err = positiveIntQuery(c, "limit", defaultAuditPageSize, maxAuditPageSize); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, total, err := h.auditRepo.Search(c.Request.Context(), search)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"page":    search.Page,
+		"limit":   search.Limit,
+		"total":   total,
+	})
+}
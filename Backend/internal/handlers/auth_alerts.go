@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+)
+
+// securityAlertTexts are the alerts sent for auth events, in Thai and English
+var securityAlertTexts = map[string][2]string{
+	models.AuditAuthPasswordChanged:   {"รหัสผ่านของบัญชีถูกเปลี่ยน", "Your password was changed"},
+	models.AuditAuthPasswordReset:     {"รหัสผ่านของบัญชีถูกรีเซ็ต", "Your password was reset"},
+	models.AuditAuthEmailChanged:      {"อีเมลของบัญชีถูกเปลี่ยน", "The email of your account was changed"},
+	models.AuditAuthTwoFactorEnabled:  {"เปิดใช้งานการยืนยันตัวตนสองขั้นตอน (2FA) แล้ว", "Two-factor authentication was turned on"},
+	models.AuditAuthTwoFactorDisabled: {"ปิดใช้งานการยืนยันตัวตนสองขั้นตอน (2FA) แล้ว", "Two-factor authentication was turned off"},
+}
+
+// securityAlert tells the user about a change to their account, on the
+// channels they chose. Failures are logged and never fail the request
+func (h *AuthHandler) securityAlert(c *gin.Context, user *models.User, action string) {
+	texts, ok := securityAlertTexts[action]
+	if !ok {
+		return
+	}
+	when := time.Now().In(draws.Location).Format("02/01/2006 15:04")
+	ip := c.ClientIP()
+
+	err := h.userNotifier.Notify(c.Request.Context(), user, models.EventSecurityAlert, func(lang string) notify.Message {
+		if lang == models.LanguageEnglish {
+			return notify.Message{
+				Subject: "Lotterich - Security alert",
+				Text: fmt.Sprintf("🔒 %s\n\nTime: %s\nIP: %s\n\nIf this wasn't you, reset your password right away.",
+					texts[1], when, ip),
+			}
+		}
+		return notify.Message{
+			Subject: "Lotterich - แจ้งเตือนความปลอดภัย",
+			Text: fmt.Sprintf("🔒 %s\n\nเวลา: %s\nIP: %s\n\nหากไม่ใช่คุณ กรุณารีเซ็ตรหัสผ่านทันที",
+				texts[0], when, ip),
+		}
+	})
+	if err != nil {
+		fmt.Printf("Failed to send security alert: %v\n", err)
+	}
+}
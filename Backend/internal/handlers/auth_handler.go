@@ -1,31 +1,55 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/user/Lotterich/internal/jobs"
 	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/permissions"
 	"github.com/user/Lotterich/internal/repositories"
+	"github.com/user/Lotterich/internal/throttle"
 	"github.com/user/Lotterich/internal/utils"
 )
 
 // AuthHandler handles authentication related requests
 type AuthHandler struct {
-	userRepo       *repositories.UserRepository
-	collectionRepo *repositories.CollectionRepository
-	otpRepo        *repositories.OTPRepository
+	userRepo         *repositories.UserRepository
+	collectionRepo   *repositories.CollectionRepository
+	otpRepo          *repositories.OTPRepository
+	refreshTokenRepo *repositories.RefreshTokenRepository
+	sessionRepo      *repositories.SessionRepository
+	auditRepo        *repositories.AuditLogRepository
+	preferencesRepo  *repositories.PreferencesRepository
+	mailer           notify.Notifier
+	userNotifier     *jobs.UserNotifier
+	loginGuard       *throttle.Guard
+	otpGuard         *throttle.Guard
+	resendGuard      *throttle.Guard
 }
 
 // NewAuthHandler creates a new AuthHandler
-func NewAuthHandler(userRepo *repositories.UserRepository, collectionRepo *repositories.CollectionRepository, otpRepo *repositories.OTPRepository) *AuthHandler {
+func NewAuthHandler(userRepo *repositories.UserRepository, collectionRepo *repositories.CollectionRepository, otpRepo *repositories.OTPRepository, refreshTokenRepo *repositories.RefreshTokenRepository, sessionRepo *repositories.SessionRepository, auditRepo *repositories.AuditLogRepository, preferencesRepo *repositories.PreferencesRepository, mailer notify.Notifier, userNotifier *jobs.UserNotifier, loginGuard, otpGuard, resendGuard *throttle.Guard) *AuthHandler {
 	return &AuthHandler{
-		userRepo:       userRepo,
-		collectionRepo: collectionRepo,
-		otpRepo:        otpRepo,
+		userRepo:         userRepo,
+		collectionRepo:   collectionRepo,
+		otpRepo:          otpRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		auditRepo:        auditRepo,
+		preferencesRepo:  preferencesRepo,
+		mailer:           mailer,
+		userNotifier:     userNotifier,
+		loginGuard:       loginGuard,
+		otpGuard:         otpGuard,
+		resendGuard:      resendGuard,
 	}
 }
 
@@ -58,9 +82,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// The account stays unverified until the emailed OTP is confirmed
+	// If sending fails the user can ask for a new code with resend-verification
+	h.recordResend(c, createdUser.Email)
+	if err := h.sendOTP(c.Request.Context(), createdUser.Email, models.OTPPurposeEmailVerify); err != nil {
+		fmt.Printf("Failed to send verification email: %v\n", err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
-		"user":    createdUser.ToResponse(),
+		"message": "User registered successfully. Please verify your email",
+		"user":    userResponse(createdUser),
 	})
 }
 
@@ -72,32 +103,231 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if !h.allowAttempt(c, h.loginGuard, input.Email) {
+		return
+	}
+
 	// Find user by email
 	user, err := h.userRepo.FindByEmail(input.Email)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "อีเมลไม่ถูกต้อง"})
+		// ไม่บันทึก audit log สำหรับอีเมลที่ไม่มีบัญชี ไม่งั้นใครก็ส่งอีเมลสุ่มมาเติม log ได้ไม่จำกัด
+		// ความพยายามนี้ถูกนับใน loginGuard (ต่ออีเมลและต่อ IP) แทน
+		if h.failAttempt(c, h.loginGuard, input.Email) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "อีเมลไม่ถูกต้อง"})
+		}
 		return
 	}
 
 	// Verify password
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "รหัสผ่านไม่ถูกต้อง"})
+		recordAuthEvent(c, h.auditRepo, models.AuditAuthLoginFailed, user.ID.Hex(), user.Email, map[string]interface{}{"reason": "wrong_password"})
+		if h.failAttempt(c, h.loginGuard, input.Email) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "รหัสผ่านไม่ถูกต้อง"})
+		}
+		return
+	}
+	if !allowLogin(c, user) {
+		return
+	}
+
+	// With 2FA on, the password only earns a ticket for the second step (POST /auth/login/2fa).
+	// ยังไม่ล้างตัวนับของ loginGuard ตรงนี้ ไม่งั้นคนที่รู้รหัสผ่านจะล้างตัวนับได้ทุกครั้ง
+	// แล้วเดารหัส 2FA ได้ไม่จำกัด ตัวนับถูกล้างใน consumeCode หลังผ่านขั้นที่สองแล้วเท่านั้น
+	if user.TwoFactorEnabled {
+		ticket, err := utils.GenerateOpaqueToken(32)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create 2FA ticket"})
+			return
+		}
+		codeHash := utils.HashOTP(user.Email, models.OTPPurposeTwoFactorLogin, ticket)
+		if err := h.otpRepo.Issue(user.Email, models.OTPPurposeTwoFactorLogin, codeHash, models.TwoFactorTicketTTL); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create 2FA ticket"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":           "กรุณายืนยันตัวตนด้วยรหัสจากแอป Authenticator",
+			"twoFactorRequired": true,
+			"twoFactorTicket":   ticket,
+			"expiresIn":         int(models.TwoFactorTicketTTL.Seconds()),
+		})
+		return
+	}
+
+	h.succeedAttempt(c, h.loginGuard, input.Email)
+	h.startSession(c, user, input.RememberMe, input.Device)
+}
+
+// allowLogin writes a 403 and returns false when an admin has suspended the
+// account or required a password reset
+func allowLogin(c *gin.Context, user *models.User) bool {
+	if user.Suspended {
+		c.JSON(http.StatusForbidden, gin.H{"error": "บัญชีนี้ถูกระงับการใช้งาน", "code": "account_suspended"})
+		return false
+	}
+	if user.PasswordResetRequired {
+		c.JSON(http.StatusForbidden, gin.H{"error": "กรุณาตั้งรหัสผ่านใหม่ผ่านเมนูลืมรหัสผ่าน", "code": "password_reset_required"})
+		return false
+	}
+	return true
+}
+
+// startSession records a new session for a user who has fully logged in and
+// responds with its tokens
+func (h *AuthHandler) startSession(c *gin.Context, user *models.User, rememberMe bool, device string) {
+	session := models.Session{
+		UserID:     user.ID.Hex(),
+		Device:     device,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		ExpiresAt:  time.Now().Add(utils.RefreshTokenTTL(rememberMe)),
+		RememberMe: rememberMe,
+	}
+	if session.Device == "" {
+		session.Device = describeDevice(session.UserAgent)
+	}
+	if err := h.sessionRepo.Create(c, &session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
 		return
 	}
 
-	// Generate token (ส่ง input.RememberMe ไปด้วย)
-	token, err := utils.GenerateJWT(user.ID.Hex(), user.Name, user.Email, user.Role, input.RememberMe)
+	// Generate access token + refresh token (rememberMe กำหนดอายุ refresh token)
+	tokens, err := h.issueTokens(c, user, rememberMe, session.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-		"user":    user.ToResponse(),
+	recordAuthEvent(c, h.auditRepo, models.AuditAuthLogin, user.ID.Hex(), user.Email, map[string]interface{}{
+		"sessionId": session.ID.Hex(),
+		"device":    session.Device,
+		"twoFactor": user.TwoFactorEnabled,
 	})
+
+	tokens["message"] = "Login successful"
+	tokens["user"] = userResponse(user)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token
+// The presented refresh token is revoked; presenting it again revokes the whole login
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var input models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	stored, err := h.refreshTokenRepo.FindByHash(ctx, utils.HashToken(input.RefreshToken))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expired"})
+		return
+	}
+
+	// Rotate: a token can only be used once
+	rotated, err := h.refreshTokenRepo.Revoke(ctx, stored.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+	if !rotated {
+		// Token reuse: somebody else holds a copy, so end this login everywhere
+		if err := h.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			fmt.Printf("Failed to revoke refresh token family: %v\n", err)
+		}
+		if _, err := h.sessionRepo.Revoke(ctx, stored.UserID, stored.FamilyID); err != nil {
+			fmt.Printf("Failed to revoke session: %v\n", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token already used"})
+		return
+	}
+
+	session, err := h.sessionRepo.GetByID(ctx, stored.FamilyID.Hex())
+	if err != nil || !session.Active(time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been logged out"})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if !allowLogin(c, user) {
+		return
+	}
+
+	tokens, err := h.issueTokens(c, user, stored.RememberMe, stored.FamilyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	if err := h.sessionRepo.Extend(ctx, session.ID, time.Now().Add(utils.RefreshTokenTTL(stored.RememberMe))); err != nil {
+		fmt.Printf("Failed to extend session: %v\n", err)
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the login that the refresh token belongs to
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var input models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	stored, err := h.refreshTokenRepo.FindByHash(ctx, utils.HashToken(input.RefreshToken))
+	if err == nil {
+		if err := h.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+			return
+		}
+		if _, err := h.sessionRepo.Revoke(ctx, stored.UserID, stored.FamilyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+			return
+		}
+	}
+
+	// Unknown tokens are treated as already logged out
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// issueTokens creates an access token and a refresh token for a session
+// The session ID doubles as the refresh token family
+func (h *AuthHandler) issueTokens(ctx context.Context, user *models.User, rememberMe bool, familyID primitive.ObjectID) (gin.H, error) {
+	accessToken, err := utils.GenerateJWT(user.ID.Hex(), user.Name, user.Email, user.Role, familyID.Hex(), user.EmailVerified, permissions.For(user.Role))
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	refreshTTL := utils.RefreshTokenTTL(rememberMe)
+	stored := models.RefreshToken{
+		UserID:     user.ID.Hex(),
+		FamilyID:   familyID,
+		TokenHash:  utils.HashToken(refreshToken),
+		RememberMe: rememberMe,
+		ExpiresAt:  time.Now().Add(refreshTTL),
+	}
+	if err := h.refreshTokenRepo.Create(ctx, &stored); err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"token":            accessToken,
+		"expiresIn":        int(utils.AccessTokenTTL().Seconds()),
+		"refreshToken":     refreshToken,
+		"refreshExpiresIn": int(refreshTTL.Seconds()),
+	}, nil
 }
 
 // GetCurrentUser retrieves the current authenticated user
@@ -117,7 +347,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"user": user.ToResponse(),
+		"user": userResponse(user),
 	})
 }
 
@@ -147,7 +377,7 @@ func (h *AuthHandler) UpdateCurrentUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, gin.H{"user": user.ToResponse()})
+	c.JSON(200, gin.H{"user": userResponse(user)})
 }
 
 // ChangePassword handles password change requests
@@ -191,7 +421,26 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
+	// Sign out every other login and replace the refresh token of this one
+	ctx := c.Request.Context()
+	currentID, _ := primitive.ObjectIDFromHex(c.GetString("sessionID"))
+	if _, err := h.sessionRepo.RevokeOthers(ctx, userID.(string), currentID); err != nil {
+		fmt.Printf("Failed to revoke sessions: %v\n", err)
+	}
+	if err := h.refreshTokenRepo.RevokeAllForUser(ctx, userID.(string)); err != nil {
+		fmt.Printf("Failed to revoke refresh tokens: %v\n", err)
+	}
+	recordAuthEvent(c, h.auditRepo, models.AuditAuthPasswordChanged, user.ID.Hex(), user.Email, nil)
+	h.securityAlert(c, user, models.AuditAuthPasswordChanged)
+
+	response := gin.H{}
+	if session, err := h.sessionRepo.GetByID(ctx, currentID.Hex()); err == nil {
+		if tokens, err := h.issueTokens(ctx, user, session.RememberMe, session.ID); err == nil {
+			response = tokens
+		}
+	}
+	response["message"] = "Password updated successfully"
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteAccount handles account deletion requests
@@ -215,11 +464,21 @@ func (h *AuthHandler) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	// Verify current password
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "รหัสผ่านปัจจุบันไม่ถูกต้อง"})
-		return
+	// Confirm with either an account deletion OTP or the current password
+	if input.OTP != "" {
+		if !h.verifyCode(c, user.Email, models.OTPPurposeAccountDeletion, input.OTP) {
+			return
+		}
+	} else {
+		if input.CurrentPassword == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุรหัสผ่านปัจจุบันหรือรหัส OTP"})
+			return
+		}
+		err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "รหัสผ่านปัจจุบันไม่ถูกต้อง"})
+			return
+		}
 	}
 
 	// Delete all collections for this user (by email)
@@ -234,6 +493,20 @@ func (h *AuthHandler) DeleteAccount(c *gin.Context) {
 		return
 	}
 
+	// Sign out every existing login
+	h.revokeAllLogins(c.Request.Context(), userID.(string))
+	if err := h.otpRepo.DeleteByEmail(user.Email); err != nil {
+		fmt.Printf("Failed to delete OTPs: %v\n", err)
+	}
+	if err := h.preferencesRepo.Delete(c.Request.Context(), userID.(string)); err != nil {
+		fmt.Printf("Failed to delete preferences: %v\n", err)
+	}
+	method := "password"
+	if input.OTP != "" {
+		method = "otp"
+	}
+	recordAuthEvent(c, h.auditRepo, models.AuditAuthAccountDeleted, user.ID.Hex(), user.Email, map[string]interface{}{"confirmedWith": method})
+
 	c.JSON(http.StatusOK, gin.H{"message": "บัญชีได้ถูกลบเรียบร้อยแล้ว"})
 }
 
@@ -257,19 +530,8 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	}
 	fmt.Printf("User found: %s\n", user.Email)
 
-	otp := utils.GenerateOTP(6)
-	fmt.Printf("Generated OTP: %s\n", otp)
-
-	if err := h.otpRepo.CreateOrUpdate(req.Email, otp); err != nil {
-		fmt.Printf("Failed to create/update OTP: %v\n", err)
-		c.JSON(500, gin.H{"error": "Failed to create OTP"})
-		return
-	}
-
-	subject := "Lotterich - รหัส OTP สำหรับรีเซ็ตรหัสผ่าน"
-	body := "รหัส OTP สำหรับรีเซ็ตรหัสผ่านของคุณคือ: " + otp + "\nรหัสนี้จะหมดอายุใน 3 นาที"
-	if err := utils.SendEmail(req.Email, subject, body); err != nil {
-		fmt.Printf("Failed to send email: %v\n", err)
+	if err := h.sendOTP(c.Request.Context(), user.Email, models.OTPPurposePasswordReset); err != nil {
+		fmt.Printf("Failed to send OTP: %v\n", err)
 		c.JSON(500, gin.H{"error": "Failed to send OTP email"})
 		return
 	}
@@ -279,6 +541,7 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 }
 
 // POST /auth/verify-otp
+// A correct OTP is used up and exchanged for a short-lived reset ticket
 func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 	var req struct {
 		Email string `json:"email"`
@@ -288,53 +551,66 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid request"})
 		return
 	}
-	otp, err := h.otpRepo.FindByEmail(req.Email)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "รหัส OTP ไม่ถูกต้อง"})
+	if !h.verifyCode(c, req.Email, models.OTPPurposePasswordReset, req.OTP) {
 		return
 	}
-	if otp.NumberOTP != req.OTP {
-		c.JSON(400, gin.H{"error": "รหัส OTP ไม่ถูกต้อง"})
+
+	ticket, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create reset ticket"})
 		return
 	}
-	if time.Since(otp.DateOTP) > 3*time.Minute {
-		// ลบ OTP ที่หมดอายุ
-		h.otpRepo.DeleteByEmail(req.Email)
-		c.JSON(400, gin.H{"error": "OTP expired"})
+	codeHash := utils.HashOTP(req.Email, models.OTPPurposeResetTicket, ticket)
+	if err := h.otpRepo.Issue(req.Email, models.OTPPurposeResetTicket, codeHash, models.ResetTicketTTL); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create reset ticket"})
 		return
 	}
-	c.JSON(200, gin.H{"message": "รหัส OTP หมดอายุ"})
+
+	c.JSON(200, gin.H{
+		"message":     "ยืนยันรหัส OTP สำเร็จ",
+		"resetTicket": ticket,
+		"expiresIn":   int(models.ResetTicketTTL.Seconds()),
+	})
 }
 
 // POST /auth/reset-password
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req struct {
-		Email       string `json:"email"`
-		OTP         string `json:"otp"`
-		NewPassword string `json:"newPassword"`
+		Email       string `json:"email" binding:"required"`
+		ResetTicket string `json:"resetTicket" binding:"required"`
+		NewPassword string `json:"newPassword" binding:"required,min=6"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": "Invalid request"})
 		return
 	}
-	otp, err := h.otpRepo.FindByEmail(req.Email)
+	if !h.verifyCode(c, req.Email, models.OTPPurposeResetTicket, req.ResetTicket) {
+		return
+	}
+
+	user, err := h.userRepo.FindByEmail(req.Email)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "รหัส OTP ไม่ถูกต้อง"})
+		c.JSON(404, gin.H{"error": "ไม่พบอีเมลนี้ในฐานข้อมูล"})
 		return
 	}
-	if otp.NumberOTP != req.OTP {
-		c.JSON(400, gin.H{"error": "รหัส OTP ไม่ถูกต้อง"})
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to hash new password"})
 		return
 	}
-	if time.Since(otp.DateOTP) > 3*time.Minute {
-		// ลบ OTP ที่หมดอายุ
-		h.otpRepo.DeleteByEmail(req.Email)
-		c.JSON(400, gin.H{"error": "OTP expired"})
+	if err := h.userRepo.UpdatePassword(user.ID.Hex(), hash); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to update password"})
 		return
 	}
-	hash, _ := utils.HashPassword(req.NewPassword)
-	user, _ := h.userRepo.FindByEmail(req.Email)
-	h.userRepo.UpdatePassword(user.ID.Hex(), hash)
-	h.otpRepo.DeleteByEmail(req.Email)
+	h.revokeAllLogins(c.Request.Context(), user.ID.Hex())
+	recordAuthEvent(c, h.auditRepo, models.AuditAuthPasswordReset, user.ID.Hex(), user.Email, nil)
+	h.securityAlert(c, user, models.AuditAuthPasswordReset)
 	c.JSON(200, gin.H{"message": "รีเซ็ตรหัสผ่านสำเร็จ"})
 }
+
+// userResponse converts a user for the API, including the permissions of their role
+func userResponse(user *models.User) models.UserResponse {
+	resp := user.ToResponse()
+	resp.Permissions = permissions.For(user.Role)
+	return resp
+}
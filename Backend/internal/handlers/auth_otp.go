@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/throttle"
+	"github.com/user/Lotterich/internal/utils"
+)
+
+// otpEmailSubjects คือหัวข้ออีเมลของ OTP แต่ละจุดประสงค์
+var otpEmailSubjects = map[string]string{
+	models.OTPPurposePasswordReset:   "Lotterich - รหัส OTP สำหรับรีเซ็ตรหัสผ่าน",
+	models.OTPPurposeEmailChange:     "Lotterich - รหัส OTP สำหรับเปลี่ยนอีเมล",
+	models.OTPPurposeAccountDeletion: "Lotterich - รหัส OTP สำหรับลบบัญชี",
+	models.OTPPurposeEmailVerify:     "Lotterich - รหัส OTP สำหรับยืนยันอีเมล",
+}
+
+// RequestOTP sends an OTP for email change or account deletion to the current user's email
+// POST /api/users/me/otp
+func (h *AuthHandler) RequestOTP(c *gin.Context) {
+	var input models.RequestOTPRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.sendOTP(c.Request.Context(), user.Email, input.Purpose); err != nil {
+		fmt.Printf("Failed to send OTP: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send OTP email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "OTP sent"})
+}
+
+// ChangeEmail moves the account and its collections to a new email
+// The OTP (purpose email_change) is sent to the current email
+// POST /api/users/me/email
+func (h *AuthHandler) ChangeEmail(c *gin.Context) {
+	var input models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	newEmail := strings.TrimSpace(input.NewEmail)
+
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "อีเมลใหม่ต้องไม่ซ้ำกับอีเมลเดิม"})
+		return
+	}
+	if !h.verifyCode(c, user.Email, models.OTPPurposeEmailChange, input.OTP) {
+		return
+	}
+	// ตรวจหลังรหัส OTP ผ่านแล้ว ไม่ให้ใช้ endpoint นี้เช็กว่าอีเมลไหนมีบัญชีอยู่
+	if _, err := h.userRepo.FindByEmail(newEmail); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "อีเมลนี้ถูกใช้งานแล้ว"})
+		return
+	}
+
+	oldEmail, oldVerified := user.Email, user.EmailVerified
+	// The new address has to be verified again
+	if err := h.userRepo.UpdateEmail(user.ID.Hex(), newEmail); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email"})
+		return
+	}
+	// MongoDB แบบ standalone ไม่มี transaction ถ้าย้ายสลากไม่สำเร็จให้ย้อนทั้งสองที่กลับเป็นอีเมลเดิม
+	if err := h.collectionRepo.UpdateEmail(oldEmail, newEmail); err != nil {
+		fmt.Printf("Failed to move collections to %s: %v\n", newEmail, err)
+		if err := h.collectionRepo.UpdateEmail(newEmail, oldEmail); err != nil {
+			fmt.Printf("Failed to move collections back to %s: %v\n", oldEmail, err)
+		}
+		if err := h.userRepo.RestoreEmail(user.ID.Hex(), oldEmail, oldVerified); err != nil {
+			fmt.Printf("Failed to restore email of user %s: %v\n", user.ID.Hex(), err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move collections to the new email"})
+		return
+	}
+
+	// Other sessions still carry the old email in their tokens, so sign them out
+	ctx := c.Request.Context()
+	currentID, _ := primitive.ObjectIDFromHex(c.GetString("sessionID"))
+	if _, err := h.sessionRepo.RevokeOthers(ctx, user.ID.Hex(), currentID); err != nil {
+		fmt.Printf("Failed to revoke sessions: %v\n", err)
+	}
+	if err := h.refreshTokenRepo.RevokeAllForUser(ctx, user.ID.Hex()); err != nil {
+		fmt.Printf("Failed to revoke refresh tokens: %v\n", err)
+	}
+	// แจ้งอีเมลเดิม เพื่อให้เจ้าของบัญชีรู้ถ้าไม่ได้เปลี่ยนเอง
+	h.securityAlert(c, user, models.AuditAuthEmailChanged)
+	user.Email = newEmail
+	user.EmailVerified = false
+	recordAuthEvent(c, h.auditRepo, models.AuditAuthEmailChanged, user.ID.Hex(), newEmail, map[string]interface{}{
+		"from": oldEmail,
+		"to":   newEmail,
+	})
+	h.recordResend(c, newEmail)
+	if err := h.sendOTP(c.Request.Context(), newEmail, models.OTPPurposeEmailVerify); err != nil {
+		fmt.Printf("Failed to send verification email: %v\n", err)
+	}
+
+	// The access token carries the email, so replace it for this session
+	response := gin.H{}
+	if session, err := h.sessionRepo.GetByID(ctx, currentID.Hex()); err == nil {
+		if tokens, err := h.issueTokens(c, user, session.RememberMe, session.ID); err == nil {
+			response = tokens
+		}
+	}
+	response["message"] = "เปลี่ยนอีเมลสำเร็จ"
+	response["user"] = userResponse(user)
+	c.JSON(http.StatusOK, response)
+}
+
+// sendOTP issues a new code for email and purpose and emails it
+func (h *AuthHandler) sendOTP(ctx context.Context, email, purpose string) error {
+	code, err := utils.GenerateOTP(6)
+	if err != nil {
+		return err
+	}
+	ttl := models.OTPLifetime(purpose)
+	codeHash := utils.HashOTP(email, purpose, code)
+	if err := h.otpRepo.Issue(email, purpose, codeHash, ttl); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("รหัส OTP ของคุณคือ: %s\nรหัสนี้จะหมดอายุใน %d นาที และใช้ได้ครั้งเดียว", code, int(ttl.Minutes()))
+	return h.mailer.Notify(ctx, notify.Message{
+		To:      email,
+		Subject: otpEmailSubjects[purpose],
+		Text:    body,
+		// A late OTP email is useless, so it is dropped once the code expires
+		Key:       "otp:" + codeHash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+}
+
+// verifyCode checks a code (or reset ticket) for email and purpose and uses it up
+// It writes the error response itself and returns false when the code is not accepted
+func (h *AuthHandler) verifyCode(c *gin.Context, email, purpose, code string) bool {
+	otp, ok := h.checkCode(c, h.otpGuard, email, purpose, code)
+	if !ok {
+		return false
+	}
+	return h.consumeCode(c, h.otpGuard, email, otp)
+}
+
+// checkCode checks a code without using it up. Wrong codes count against guard,
+// and a code is cancelled after models.MaxOTPAttempts wrong tries
+func (h *AuthHandler) checkCode(c *gin.Context, guard *throttle.Guard, email, purpose, code string) (*models.OTP, bool) {
+	if !h.allowAttempt(c, guard, email) {
+		return nil, false
+	}
+
+	otp, err := h.otpRepo.Find(email, purpose)
+	if err != nil || time.Now().After(otp.ExpiresAt) {
+		if h.failAttempt(c, guard, email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รหัส OTP ไม่ถูกต้องหรือหมดอายุ"})
+		}
+		return nil, false
+	}
+
+	hash := utils.HashOTP(email, purpose, code)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(otp.CodeHash)) != 1 {
+		h.failCode(c, guard, email, otp, "รหัส OTP ไม่ถูกต้อง")
+		return nil, false
+	}
+	return otp, true
+}
+
+// failCode counts a failed attempt against a stored code and writes the error response
+func (h *AuthHandler) failCode(c *gin.Context, guard *throttle.Guard, email string, otp *models.OTP, message string) {
+	attempts, err := h.otpRepo.RecordFailedAttempt(otp.ID)
+	if err != nil {
+		fmt.Printf("Failed to record OTP attempt: %v\n", err)
+	}
+	burned := attempts >= models.MaxOTPAttempts
+	if burned {
+		h.otpRepo.Delete(otp.ID)
+	}
+
+	if !h.failAttempt(c, guard, email) {
+		return
+	}
+	if burned {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ใส่รหัสผิดเกินจำนวนครั้งที่กำหนด กรุณาเริ่มใหม่อีกครั้ง"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": message})
+}
+
+// consumeCode uses up a code that passed checkCode
+// Single use: only the request that deletes the code may go on
+func (h *AuthHandler) consumeCode(c *gin.Context, guard *throttle.Guard, email string, otp *models.OTP) bool {
+	used, err := h.otpRepo.Delete(otp.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify OTP"})
+		return false
+	}
+	if !used {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัส OTP นี้ถูกใช้ไปแล้ว"})
+		return false
+	}
+
+	h.succeedAttempt(c, guard, email)
+	return true
+}
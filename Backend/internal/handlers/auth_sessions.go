@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetSessions lists the active sessions of the current user
+// GET /api/users/me/sessions
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID := c.GetString("userID")
+	currentID := c.GetString("sessionID")
+
+	sessions, err := h.sessionRepo.FindActiveByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load sessions"})
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.Hex() == currentID
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession logs out one session of the current user
+// DELETE /api/users/me/sessions/:id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID := c.GetString("userID")
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	revoked, err := h.sessionRepo.Revoke(ctx, userID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบ session"})
+		return
+	}
+	if err := h.refreshTokenRepo.RevokeFamily(ctx, id); err != nil {
+		fmt.Printf("Failed to revoke refresh token family: %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ออกจากระบบอุปกรณ์นั้นเรียบร้อย"})
+}
+
+// RevokeOtherSessions logs out every session of the current user except this one
+// DELETE /api/users/me/sessions
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID := c.GetString("userID")
+	currentID, err := primitive.ObjectIDFromHex(c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	revoked, err := h.sessionRepo.RevokeOthers(ctx, userID, currentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	if err := h.refreshTokenRepo.RevokeFamilies(ctx, revoked); err != nil {
+		fmt.Printf("Failed to revoke refresh token families: %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "ออกจากระบบอุปกรณ์อื่นทั้งหมดเรียบร้อย",
+		"revoked": len(revoked),
+	})
+}
+
+// revokeAllLogins ends every session and refresh token of a user
+func (h *AuthHandler) revokeAllLogins(ctx context.Context, userID string) {
+	if err := h.sessionRepo.RevokeAllForUser(ctx, userID); err != nil {
+		fmt.Printf("Failed to revoke sessions: %v\n", err)
+	}
+	if err := h.refreshTokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+		fmt.Printf("Failed to revoke refresh tokens: %v\n", err)
+	}
+}
+
+// describeDevice turns a User-Agent into a short label such as "Chrome on Windows"
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"samsungbrowser", "Samsung Internet"},
+		{"line/", "LINE"},
+		{"chrome/", "Chrome"},
+		{"crios", "Chrome"},
+		{"firefox/", "Firefox"},
+		{"fxios", "Firefox"},
+		{"safari/", "Safari"},
+		{"postman", "Postman"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, o := range []struct{ token, name string }{
+		{"iphone", "iPhone"},
+		{"ipad", "iPad"},
+		{"android", "Android"},
+		{"windows", "Windows"},
+		{"mac os", "macOS"},
+		{"cros", "ChromeOS"},
+		{"linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			platform = o.name
+			break
+		}
+	}
+
+	if platform == "" {
+		return browser
+	}
+	return browser + " on " + platform
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/throttle"
+)
+
+// allowAttempt checks the guard before a login or OTP attempt
+// It writes a 429 response and returns false when the account or IP is locked out
+func (h *AuthHandler) allowAttempt(c *gin.Context, guard *throttle.Guard, account string) bool {
+	wait, err := guard.Allow(c.Request.Context(), account, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login attempts"})
+		return false
+	}
+	if wait > 0 {
+		tooManyAttempts(c, wait)
+		return false
+	}
+	return true
+}
+
+// failAttempt records a failed attempt. It returns false after writing a
+// response itself (429 when this failure caused a lockout, 500 on error)
+func (h *AuthHandler) failAttempt(c *gin.Context, guard *throttle.Guard, account string) bool {
+	wait, err := guard.Fail(c.Request.Context(), account, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record login attempt"})
+		return false
+	}
+	if wait > 0 {
+		tooManyAttempts(c, wait)
+		return false
+	}
+	return true
+}
+
+// succeedAttempt clears the account counter after a successful attempt
+func (h *AuthHandler) succeedAttempt(c *gin.Context, guard *throttle.Guard, account string) {
+	if err := guard.Succeed(c.Request.Context(), account); err != nil {
+		fmt.Printf("Failed to reset login attempts: %v\n", err)
+	}
+}
+
+// tooManyAttempts writes a 429 response with a Retry-After header in seconds
+func tooManyAttempts(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":      fmt.Sprintf("พยายามหลายครั้งเกินไป กรุณาลองใหม่ใน %d วินาที", seconds),
+		"retryAfter": seconds,
+	})
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/twofactor"
+	"github.com/user/Lotterich/internal/utils"
+)
+
+// LoginTwoFactor is the second step of a login with 2FA: it exchanges the ticket
+// from Login plus a TOTP or recovery code for tokens
+// POST /api/auth/login/2fa
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var input models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, ok := h.checkCode(c, h.loginGuard, input.Email, models.OTPPurposeTwoFactorLogin, input.Ticket)
+	if !ok {
+		return
+	}
+
+	user, err := h.userRepo.FindByEmail(input.Email)
+	if err != nil || !user.TwoFactorEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ไม่พบผู้ใช้งานนี้"})
+		return
+	}
+	if !allowLogin(c, user) {
+		return
+	}
+
+	valid, err := h.verifySecondFactor(user, input.Code, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !valid {
+		recordAuthEvent(c, h.auditRepo, models.AuditAuthLoginFailed, user.ID.Hex(), user.Email, map[string]interface{}{"reason": "wrong_second_factor"})
+		h.failCode(c, h.loginGuard, input.Email, ticket, "รหัสยืนยันตัวตนไม่ถูกต้อง")
+		return
+	}
+	if !h.consumeCode(c, h.loginGuard, input.Email, ticket) {
+		return
+	}
+
+	h.startSession(c, user, input.RememberMe, input.Device)
+}
+
+// SetupTwoFactor creates a new TOTP secret for the current user and returns it
+// with a QR code. 2FA is only turned on after EnableTwoFactor confirms a code
+// POST /api/users/me/2fa/setup
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "เปิดใช้งาน 2FA อยู่แล้ว"})
+		return
+	}
+
+	setup, err := twofactor.NewSetup(user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create 2FA secret"})
+		return
+	}
+	if err := h.userRepo.SetPendingTOTP(user.ID.Hex(), setup.Secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save 2FA secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTwoFactor confirms the secret from SetupTwoFactor with a code from the app
+// The recovery codes are returned only once
+// POST /api/users/me/2fa/enable
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var input models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "เปิดใช้งาน 2FA อยู่แล้ว"})
+		return
+	}
+	if user.TOTPPendingSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาเริ่มตั้งค่า 2FA ก่อน"})
+		return
+	}
+	if !h.allowAttempt(c, h.otpGuard, user.Email) {
+		return
+	}
+
+	step, ok := twofactor.Validate(user.TOTPPendingSecret, input.Code, time.Now(), 0)
+	if !ok {
+		if h.failAttempt(c, h.otpGuard, user.Email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสยืนยันตัวตนไม่ถูกต้อง"})
+		}
+		return
+	}
+	h.succeedAttempt(c, h.otpGuard, user.Email)
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+		return
+	}
+	if err := h.userRepo.EnableTwoFactor(user.ID.Hex(), user.TOTPPendingSecret, step, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable 2FA"})
+		return
+	}
+	recordAuthEvent(c, h.auditRepo, models.AuditAuthTwoFactorEnabled, user.ID.Hex(), user.Email, nil)
+	h.securityAlert(c, user, models.AuditAuthTwoFactorEnabled)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "เปิดใช้งาน 2FA สำเร็จ กรุณาเก็บรหัสกู้คืนไว้ในที่ปลอดภัย",
+		"recoveryCodes": codes,
+	})
+}
+
+// DisableTwoFactor turns 2FA off. It needs the password and a TOTP or recovery code
+// POST /api/users/me/2fa/disable
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var input models.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ยังไม่ได้เปิดใช้งาน 2FA"})
+		return
+	}
+	if !h.allowAttempt(c, h.otpGuard, user.Email) {
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)); err != nil {
+		if h.failAttempt(c, h.otpGuard, user.Email) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "รหัสผ่านปัจจุบันไม่ถูกต้อง"})
+		}
+		return
+	}
+	valid, err := h.verifySecondFactor(user, input.Code, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !valid {
+		if h.failAttempt(c, h.otpGuard, user.Email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสยืนยันตัวตนไม่ถูกต้อง"})
+		}
+		return
+	}
+	h.succeedAttempt(c, h.otpGuard, user.Email)
+
+	if err := h.userRepo.DisableTwoFactor(user.ID.Hex()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable 2FA"})
+		return
+	}
+	recordAuthEvent(c, h.auditRepo, models.AuditAuthTwoFactorDisabled, user.ID.Hex(), user.Email, nil)
+	h.securityAlert(c, user, models.AuditAuthTwoFactorDisabled)
+	c.JSON(http.StatusOK, gin.H{"message": "ปิดใช้งาน 2FA เรียบร้อย"})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes. It needs a TOTP code
+// POST /api/users/me/2fa/recovery-codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var input models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ยังไม่ได้เปิดใช้งาน 2FA"})
+		return
+	}
+	if !h.allowAttempt(c, h.otpGuard, user.Email) {
+		return
+	}
+
+	valid, err := h.verifySecondFactor(user, input.Code, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !valid {
+		if h.failAttempt(c, h.otpGuard, user.Email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสยืนยันตัวตนไม่ถูกต้อง"})
+		}
+		return
+	}
+	h.succeedAttempt(c, h.otpGuard, user.Email)
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+		return
+	}
+	if err := h.userRepo.SetRecoveryCodes(user.ID.Hex(), hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recovery codes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// verifySecondFactor checks a TOTP code and, when allowRecovery is set, a recovery code
+// Accepted codes are used up: a TOTP step works once and a recovery code is removed
+func (h *AuthHandler) verifySecondFactor(user *models.User, code string, allowRecovery bool) (bool, error) {
+	if step, ok := twofactor.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		return h.userRepo.UseTOTPStep(user.ID.Hex(), step)
+	}
+	if !allowRecovery {
+		return false, nil
+	}
+
+	used, err := h.userRepo.UseRecoveryCode(user.ID.Hex(), utils.HashToken(twofactor.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return false, err
+	}
+	if used {
+		fmt.Printf("Recovery code used for user %s\n", user.ID.Hex())
+	}
+	return used, nil
+}
+
+// newRecoveryCodes returns fresh recovery codes and the hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := twofactor.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(code)
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/models"
+)
+
+// VerifyEmail confirms the email of an account with the OTP sent on registration
+// POST /api/auth/verify-email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var input models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.verifyCode(c, input.Email, models.OTPPurposeEmailVerify, input.OTP) {
+		return
+	}
+	if err := h.userRepo.MarkEmailVerified(input.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	// Tokens issued before this point still say unverified until they are refreshed
+	c.JSON(http.StatusOK, gin.H{"message": "ยืนยันอีเมลสำเร็จ"})
+}
+
+// ResendVerification emails a new verification OTP
+// Sends are throttled per email and per IP
+// POST /api/auth/resend-verification
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var input models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.allowAttempt(c, h.resendGuard, input.Email) {
+		return
+	}
+
+	user, err := h.userRepo.FindByEmail(input.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบอีเมลนี้ในฐานข้อมูล"})
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "อีเมลนี้ยืนยันแล้ว"})
+		return
+	}
+
+	h.recordResend(c, user.Email)
+	if err := h.sendOTP(c.Request.Context(), user.Email, models.OTPPurposeEmailVerify); err != nil {
+		fmt.Printf("Failed to send verification email: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "OTP sent"})
+}
+
+// recordResend counts a verification email against the resend limits
+// Unlike failed logins, the send itself goes ahead; only the next one waits
+func (h *AuthHandler) recordResend(c *gin.Context, email string) {
+	if _, err := h.resendGuard.Fail(c.Request.Context(), email, c.ClientIP()); err != nil {
+		fmt.Printf("Failed to record verification email: %v\n", err)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/export"
+	"github.com/user/Lotterich/internal/models"
+)
+
+var collectionExportColumns = []string{
+	"Ticket Number", "Quantity", "Price", "Spent", "Purchase Date",
+	"Draw Date", "Result", "Prize Types", "Prize per Ticket", "Total Prize",
+}
+
+// Export ส่งออกรายการสลากพร้อมผลรางวัลและยอดรวมเป็น CSV, XLSX หรือ PDF
+// query: format (csv, xlsx, pdf) และตัวกรอง/การเรียงแบบเดียวกับ GET /api/collection
+func (h *CollectionHandler) Export(c *gin.Context) {
+	email := c.GetString("userEmail")
+	format := c.DefaultQuery("format", export.FormatCSV)
+
+	query, err := parseCollectionQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := export.NewWriter(format, c.Writer, "Lotterich Collection Statement")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format ต้องเป็น csv, xlsx หรือ pdf"})
+		return
+	}
+	setExportHeaders(c, "lotterich-collection", format)
+
+	var totalTickets, totalSpent, totalPrize, wins int
+	err = w.WriteHeader(collectionExportColumns)
+	if err == nil {
+		err = h.repo.Stream(c.Request.Context(), email, query, func(item models.Collection) error {
+			spent := item.TicketQuantity * item.TicketAmount
+			prize := 0
+			if item.PrizeAmount > 0 {
+				prize = item.PrizeAmount * item.TicketQuantity
+				wins++
+			}
+			totalTickets += item.TicketQuantity
+			totalSpent += spent
+			totalPrize += prize
+			return w.WriteRow([]string{
+				item.TicketNumber,
+				strconv.Itoa(item.TicketQuantity),
+				strconv.Itoa(item.TicketAmount),
+				strconv.Itoa(spent),
+				item.Date.In(thaiLocation).Format("2006-01-02"),
+				item.PrizeDate,
+				prizeResult(item),
+				strings.Join(item.PrizeTypes, " "),
+				strconv.Itoa(item.PrizeAmount),
+				strconv.Itoa(prize),
+			})
+		})
+	}
+	if err == nil {
+		err = w.WriteSummary([][2]string{
+			{"Total Tickets", strconv.Itoa(totalTickets)},
+			{"Total Spent", strconv.Itoa(totalSpent)},
+			{"Winning Entries", strconv.Itoa(wins)},
+			{"Total Prize", strconv.Itoa(totalPrize)},
+			{"Net Profit", strconv.Itoa(totalPrize - totalSpent)},
+		})
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// ส่ง header ไปแล้ว จึงทำได้แค่บันทึก log และตัดการเชื่อมต่อ
+		log.Printf("Failed to export collection for %s: %v", email, err)
+		c.Abort()
+	}
+}
+
+// prizeResult คืนสถานะผลรางวัลของสลาก (win, lose, pending)
+func prizeResult(item models.Collection) string {
+	switch {
+	case item.PrizeAmount > 0:
+		return "win"
+	case item.PrizeType == "lose":
+		return "lose"
+	default:
+		return "pending"
+	}
+}
+
+func setExportHeaders(c *gin.Context, name, format string) {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().In(thaiLocation).Format("20060102"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+}
@@ -1,33 +1,160 @@
 package handlers
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/user/Lotterich/internal/draws"
 	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/prize"
 	"github.com/user/Lotterich/internal/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// thaiLocation ใช้แปลงวันที่ใน query ให้ตรงกับเวลาประเทศไทย
+var thaiLocation = draws.Location
+
 type CollectionHandler struct {
 	repo           *repositories.CollectionRepository
 	statisticsRepo *repositories.StatisticsRepository
+	prizeEngine    *prize.Engine
+	schedule       *draws.Schedule
 }
 
-func NewCollectionHandler(repo *repositories.CollectionRepository, statisticsRepo *repositories.StatisticsRepository) *CollectionHandler {
-	return &CollectionHandler{repo: repo, statisticsRepo: statisticsRepo}
+func NewCollectionHandler(repo *repositories.CollectionRepository, statisticsRepo *repositories.StatisticsRepository, prizeEngine *prize.Engine, schedule *draws.Schedule) *CollectionHandler {
+	return &CollectionHandler{repo: repo, statisticsRepo: statisticsRepo, prizeEngine: prizeEngine, schedule: schedule}
 }
 
+// GetAll คืนรายการสลากของผู้ใช้ทีละหน้า (ใช้ nextCursor เพื่อขอหน้าถัดไป)
+// query: limit, cursor, numberPrefix, numberSuffix, drawFrom, drawTo,
+// result (win, lose, pending), prizeType, sort (purchaseDate, drawDate, prizeAmount), order (asc, desc)
 func (h *CollectionHandler) GetAll(c *gin.Context) {
 	email := c.GetString("userEmail")
-	items, err := h.repo.FindByEmail(email)
+
+	query, err := parseCollectionQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, nextCursor, err := h.repo.FindPage(c.Request.Context(), email, query)
+	if errors.Is(err, repositories.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cursor ไม่ถูกต้อง"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collection"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"collection": items})
+	c.JSON(http.StatusOK, gin.H{
+		"collection": items,
+		"nextCursor": nextCursor,
+		"hasMore":    nextCursor != "",
+	})
+}
+
+const (
+	defaultCollectionPageSize = 50
+	maxCollectionPageSize     = 200
+)
+
+func parseCollectionQuery(c *gin.Context) (repositories.CollectionQuery, error) {
+	q := repositories.CollectionQuery{
+		NumberPrefix: c.Query("numberPrefix"),
+		NumberSuffix: c.Query("numberSuffix"),
+		DrawFrom:     c.Query("drawFrom"),
+		DrawTo:       c.Query("drawTo"),
+		Result:       c.Query("result"),
+		PrizeType:    c.Query("prizeType"),
+		SortBy:       c.DefaultQuery("sort", repositories.SortByPurchaseDate),
+		Limit:        defaultCollectionPageSize,
+		Cursor:       c.Query("cursor"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n <= 0 {
+			return q, errors.New("limit ต้องเป็นจำนวนเต็มบวก")
+		}
+		if n > maxCollectionPageSize {
+			n = maxCollectionPageSize
+		}
+		q.Limit = n
+	}
+	for _, number := range []string{q.NumberPrefix, q.NumberSuffix} {
+		if len(number) > 6 || !isDigits(number, len(number)) {
+			return q, errors.New("numberPrefix และ numberSuffix ต้องเป็นตัวเลขไม่เกิน 6 หลัก")
+		}
+	}
+	for _, date := range []string{q.DrawFrom, q.DrawTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return q, errors.New("รูปแบบวันที่งวดไม่ถูกต้อง (YYYY-MM-DD)")
+		}
+	}
+	switch q.Result {
+	case "", repositories.ResultWin, repositories.ResultLose, repositories.ResultPending:
+	default:
+		return q, errors.New("result ต้องเป็น win, lose หรือ pending")
+	}
+	switch q.SortBy {
+	case repositories.SortByPurchaseDate, repositories.SortByDrawDate, repositories.SortByPrizeAmount:
+	default:
+		return q, errors.New("sort ต้องเป็น purchaseDate, drawDate หรือ prizeAmount")
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		q.Ascending = true
+	case "desc":
+	default:
+		return q, errors.New("order ต้องเป็น asc หรือ desc")
+	}
+	return q, nil
+}
+
+// GetSummary สรุปยอดซื้อ ยอดถูกรางวัล และกำไรสุทธิของผู้ใช้
+// query: from, to (YYYY-MM-DD ตามวันที่ซื้อ, to นับรวมวันนั้น) และ groupBy (draw, month, year)
+func (h *CollectionHandler) GetSummary(c *gin.Context) {
+	email := c.GetString("userEmail")
+
+	var opts repositories.SummaryOptions
+	if from := c.Query("from"); from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, thaiLocation)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ from ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+		opts.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, thaiLocation)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ to ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+		t = t.AddDate(0, 0, 1)
+		opts.To = &t
+	}
+	switch groupBy := c.Query("groupBy"); groupBy {
+	case "", repositories.SummaryGroupByDraw, repositories.SummaryGroupByMonth, repositories.SummaryGroupByYear:
+		opts.GroupBy = groupBy
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "groupBy ต้องเป็น draw, month หรือ year"})
+		return
+	}
+
+	summary, err := h.repo.Summarize(c.Request.Context(), email, opts, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarize collection"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
 }
 
 func (h *CollectionHandler) Create(c *gin.Context) {
@@ -44,18 +171,8 @@ func (h *CollectionHandler) Create(c *gin.Context) {
 	}
 
 	// Validate required fields
-	if input.TicketNumber == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณากรอกหมายเลขสลาก"})
-		return
-	}
-
-	if input.TicketQuantity <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "จำนวนที่ซื้อต้องมากกว่า 0"})
-		return
-	}
-
-	if input.TicketAmount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "จำนวนเงินสลากต้องมากกว่า 0 บาท"})
+	if err := validateCollection(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -65,39 +182,16 @@ func (h *CollectionHandler) Create(c *gin.Context) {
 		input.Date = time.Now()
 	}
 
-	// ตรวจรางวัลถ้ามี prize_date
-	if input.PrizeDate != "" {
-		// ดึงข้อมูล statistics ของงวดนั้น
-		stats, err := h.statisticsRepo.GetAll(c)
-		if err == nil {
-			var stat *models.Statistics
-			for _, s := range stats {
-				if s.Date == input.PrizeDate {
-					stat = &s
-					break
-				}
-			}
-			if stat != nil {
-				// ตรวจสอบรางวัล
-				prizeType, prizeAmount := checkPrize(input.TicketNumber, stat)
-				input.PrizeType = prizeType
-				input.PrizeAmount = prizeAmount
-				if input.PrizeType == "" {
-					input.PrizeType = "lose"
-				}
-			} else {
-				input.PrizeType = ""
-				input.PrizeAmount = 0
-			}
-		} else {
-			input.PrizeType = ""
-			input.PrizeAmount = 0
+	// ถ้าไม่ได้ระบุงวด ใช้งวดถัดไปนับจากวันที่ซื้อ
+	if input.PrizeDate == "" {
+		if draw, err := h.schedule.Next(c.Request.Context(), input.Date); err == nil {
+			input.PrizeDate = draw.Date
 		}
-	} else {
-		input.PrizeType = ""
-		input.PrizeAmount = 0
 	}
 
+	// ตรวจรางวัลถ้ามี prize_date
+	h.applyPrize(c, &input)
+
 	// Create item
 	item, err := h.repo.Create(input)
 	if err != nil {
@@ -121,51 +215,15 @@ func (h *CollectionHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if input.TicketNumber == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณากรอกหมายเลขสลาก"})
-		return
-	}
-	if input.TicketQuantity <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "จำนวนที่ซื้อต้องมากกว่า 0"})
-		return
-	}
-	if input.TicketAmount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "จำนวนเงินสลากต้องมากกว่า 0 บาท"})
+	if err := validateCollection(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	input.ID = objID
 	input.Email = email
 
 	// ตรวจรางวัลถ้ามี prize_date
-	if input.PrizeDate != "" {
-		stats, err := h.statisticsRepo.GetAll(c)
-		if err == nil {
-			var stat *models.Statistics
-			for _, s := range stats {
-				if s.Date == input.PrizeDate {
-					stat = &s
-					break
-				}
-			}
-			if stat != nil {
-				prizeType, prizeAmount := checkPrize(input.TicketNumber, stat)
-				input.PrizeType = prizeType
-				input.PrizeAmount = prizeAmount
-				if input.PrizeType == "" {
-					input.PrizeType = "lose"
-				}
-			} else {
-				input.PrizeType = ""
-				input.PrizeAmount = 0
-			}
-		} else {
-			input.PrizeType = ""
-			input.PrizeAmount = 0
-		}
-	} else {
-		input.PrizeType = ""
-		input.PrizeAmount = 0
-	}
+	h.applyPrize(c, &input)
 
 	if err := h.repo.Update(input); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
@@ -190,33 +248,53 @@ func (h *CollectionHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Deleted"})
 }
 
-// checkPrize ตรวจสอบรางวัลจากเลขสลากและข้อมูลสถิติ
-func checkPrize(ticketNumber string, stat *models.Statistics) (string, int) {
-	// รางวัลที่ 1
-	if ticketNumber == stat.Prize1 {
-		return "prize1", 6000000
+// validateCollection ตรวจสอบข้อมูลสลากที่ผู้ใช้กรอก (ใช้ร่วมกันทั้งเพิ่ม แก้ไข และนำเข้าไฟล์)
+func validateCollection(input *models.Collection) error {
+	if input.TicketNumber == "" {
+		return errors.New("กรุณากรอกหมายเลขสลาก")
 	}
-	// ข้างเคียงรางวัลที่ 1
-	if n, p := toInt(ticketNumber), toInt(stat.Prize1); n == p+1 || n == p-1 {
-		return "near1", 100000
+	if input.TicketQuantity <= 0 {
+		return errors.New("จำนวนที่ซื้อต้องมากกว่า 0")
 	}
-	// สามตัวหน้า
-	if len(ticketNumber) == 6 && (ticketNumber[:3] == stat.First3One || ticketNumber[:3] == stat.First3Two) {
-		return "first3", 4000
+	if input.TicketAmount <= 0 {
+		return errors.New("จำนวนเงินสลากต้องมากกว่า 0 บาท")
 	}
-	// สามตัวท้าย
-	if len(ticketNumber) == 6 && (ticketNumber[3:] == stat.Last3One || ticketNumber[3:] == stat.Last3Two) {
-		return "last3", 4000
+	return nil
+}
+
+// applyPrize ตรวจรางวัลของสลากจากผลรางวัลงวด prize_date แล้วบันทึกผลลงใน item
+// ถ้ายังไม่มี prize_date หรือยังไม่ออกผลงวดนั้น จะล้างผลรางวัลเป็นค่าว่าง (รอผล)
+func (h *CollectionHandler) applyPrize(ctx context.Context, item *models.Collection) {
+	var stat *models.Statistics
+	if item.PrizeDate != "" {
+		stat = h.findDraw(ctx, item.PrizeDate)
 	}
-	// สองตัวท้าย
-	if len(ticketNumber) == 6 && ticketNumber[4:] == stat.Last2 {
-		return "last2", 2000
+	h.applyPrizeWith(item, stat)
+}
+
+// findDraw คืนผลรางวัลของงวด date หรือ nil ถ้ายังไม่ออกผล
+func (h *CollectionHandler) findDraw(ctx context.Context, date string) *models.Statistics {
+	// ดึงข้อมูล statistics ของงวดนั้น
+	stat, err := h.statisticsRepo.GetByDate(ctx, date)
+	if err != nil {
+		return nil
 	}
-	return "", 0
+	return stat
 }
 
-func toInt(s string) int {
-	var n int
-	_, _ = fmt.Sscanf(s, "%d", &n)
-	return n
+// applyPrizeWith ตรวจรางวัลของสลากกับผลรางวัล stat (nil = รอผล)
+func (h *CollectionHandler) applyPrizeWith(item *models.Collection, stat *models.Statistics) {
+	item.PrizeType = ""
+	item.PrizeTypes = []string{}
+	item.PrizeAmount = 0
+	if stat == nil {
+		return
+	}
+
+	// ตรวจสอบรางวัลตามกติกาเงินรางวัลของงวดนั้น
+	result, err := h.prizeEngine.Check(item.TicketNumber, stat)
+	if err != nil {
+		return
+	}
+	result.ApplyTo(item)
 }
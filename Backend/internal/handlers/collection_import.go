@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/user/Lotterich/internal/models"
+)
+
+const (
+	maxImportFileSize = 5 << 20 // 5 MB
+	maxImportRows     = 5000
+)
+
+// importColumns จับคู่หัวตารางที่รองรับ (ตัวพิมพ์เล็ก ไม่มีช่องว่าง) กับช่องข้อมูลของสลาก
+var importColumns = map[string]string{
+	"ticketnumber":    "ticketNumber",
+	"ticket_number":   "ticketNumber",
+	"number":          "ticketNumber",
+	"เลขสลาก":         "ticketNumber",
+	"หมายเลขสลาก":     "ticketNumber",
+	"ticketquantity":  "ticketQuantity",
+	"ticket_quantity": "ticketQuantity",
+	"quantity":        "ticketQuantity",
+	"จำนวน":           "ticketQuantity",
+	"ticketamount":    "ticketAmount",
+	"ticket_amount":   "ticketAmount",
+	"amount":          "ticketAmount",
+	"price":           "ticketAmount",
+	"ราคา":            "ticketAmount",
+	"date":            "date",
+	"purchasedate":    "date",
+	"purchase_date":   "date",
+	"วันที่ซื้อ":      "date",
+	"prize_date":      "prizeDate",
+	"prizedate":       "prizeDate",
+	"drawdate":        "prizeDate",
+	"draw_date":       "prizeDate",
+	"งวดวันที่":       "prizeDate",
+}
+
+// ImportRow คือผลของแต่ละแถวในไฟล์นำเข้า
+type ImportRow struct {
+	Row          int    `json:"row"` // เลขแถวในไฟล์ (นับหัวตารางเป็นแถวที่ 1)
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	TicketNumber string `json:"ticketNumber,omitempty"`
+	PrizeDate    string `json:"prize_date,omitempty"`
+	PrizeType    string `json:"prizeType,omitempty"`
+	PrizeAmount  int    `json:"prizeAmount,omitempty"`
+	ID           string `json:"id,omitempty"`
+}
+
+// สถานะของแต่ละแถว
+const (
+	importRowValid    = "valid"    // ผ่านการตรวจสอบ (dry run)
+	importRowInvalid  = "invalid"  // ข้อมูลไม่ถูกต้อง
+	importRowInserted = "inserted" // บันทึกแล้ว
+	importRowSkipped  = "skipped"  // ข้อมูลถูกต้องแต่ไม่ถูกบันทึกเพราะมีแถวอื่นผิด (atomic)
+)
+
+// Import นำเข้ารายการสลากจากไฟล์ CSV หรือ XLSX (multipart field "file")
+// query: dryRun=true ตรวจสอบอย่างเดียวไม่บันทึก, atomic=true บันทึกเมื่อทุกแถวถูกต้องเท่านั้น
+func (h *CollectionHandler) Import(c *gin.Context) {
+	email := c.GetString("userEmail")
+	dryRun := c.Query("dryRun") == "true"
+	atomic := c.Query("atomic") == "true"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาแนบไฟล์ CSV หรือ XLSX ในช่อง file"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "ไฟล์ต้องมีขนาดไม่เกิน 5 MB"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถเปิดไฟล์ได้"})
+		return
+	}
+	defer file.Close()
+
+	var records [][]string
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".csv":
+		records, err = readCSV(file)
+	case ".xlsx":
+		records, err = readXLSX(file)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รองรับเฉพาะไฟล์ .csv และ .xlsx"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "อ่านไฟล์ไม่สำเร็จ: " + err.Error()})
+		return
+	}
+	if len(records) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่พบข้อมูลในไฟล์"})
+		return
+	}
+	if len(records)-1 > maxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("นำเข้าได้ครั้งละไม่เกิน %d แถว", maxImportRows)})
+		return
+	}
+
+	columns, err := mapImportColumns(records[0])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ตรวจสอบทุกแถวด้วยกติกาเดียวกับการเพิ่มสลาก และตรวจรางวัลแถวที่มี prize_date
+	draws := map[string]*models.Statistics{}
+	rows := make([]ImportRow, 0, len(records)-1)
+	items := make([]models.Collection, 0, len(records)-1)
+	itemRows := make([]int, 0, len(records)-1) // index ของ rows สำหรับแต่ละ item
+	invalid := 0
+	for i, record := range records[1:] {
+		row := ImportRow{Row: i + 2}
+		item, err := parseImportRow(record, columns)
+		if err == nil {
+			err = validateCollection(&item)
+		}
+		if err != nil {
+			row.Status = importRowInvalid
+			row.Error = err.Error()
+			row.TicketNumber = item.TicketNumber
+			rows = append(rows, row)
+			invalid++
+			continue
+		}
+
+		item.Email = email
+		if item.Date.IsZero() {
+			item.Date = time.Now()
+		}
+		var stat *models.Statistics
+		if item.PrizeDate != "" {
+			cached, ok := draws[item.PrizeDate]
+			if !ok {
+				cached = h.findDraw(c.Request.Context(), item.PrizeDate)
+				draws[item.PrizeDate] = cached
+			}
+			stat = cached
+		}
+		h.applyPrizeWith(&item, stat)
+
+		row.Status = importRowValid
+		row.TicketNumber = item.TicketNumber
+		row.PrizeDate = item.PrizeDate
+		row.PrizeType = item.PrizeType
+		row.PrizeAmount = item.PrizeAmount
+		rows = append(rows, row)
+		items = append(items, item)
+		itemRows = append(itemRows, len(rows)-1)
+	}
+
+	inserted := 0
+	status := http.StatusOK
+	switch {
+	case dryRun:
+	case atomic && invalid > 0:
+		for _, idx := range itemRows {
+			rows[idx].Status = importRowSkipped
+		}
+		status = http.StatusUnprocessableEntity
+	default:
+		created, err := h.repo.CreateMany(c.Request.Context(), items)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import collection: " + err.Error()})
+			return
+		}
+		for i, idx := range itemRows {
+			rows[idx].Status = importRowInserted
+			rows[idx].ID = created[i].ID.Hex()
+		}
+		inserted = len(created)
+	}
+
+	c.JSON(status, gin.H{
+		"dryRun":   dryRun,
+		"atomic":   atomic,
+		"total":    len(rows),
+		"valid":    len(items),
+		"invalid":  invalid,
+		"inserted": inserted,
+		"rows":     rows,
+	})
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	// ตัด BOM ที่ Excel ใส่ไว้หน้าไฟล์ CSV แบบ UTF-8
+	if len(records) > 0 && len(records[0]) > 0 {
+		records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	}
+	return records, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("ไม่พบแผ่นงานในไฟล์")
+	}
+	// ใช้ค่าดิบของเซลล์ เพื่อให้วันที่เป็นเลขลำดับวันของ Excel แทนข้อความที่จัดรูปแบบแล้ว
+	return f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+}
+
+func mapImportColumns(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, name := range header {
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", ""))
+		if field, ok := importColumns[key]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+	for _, required := range []string{"ticketNumber", "ticketQuantity", "ticketAmount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("ไม่พบคอลัมน์ %s ในหัวตาราง", required)
+		}
+	}
+	return columns, nil
+}
+
+func parseImportRow(record []string, columns map[string]int) (models.Collection, error) {
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var item models.Collection
+	item.TicketNumber = cell("ticketNumber")
+	// Excel ตัดเลข 0 หน้าเลขสลากทิ้ง จึงเติมกลับให้ครบ 6 หลัก
+	if n := len(item.TicketNumber); n > 0 && n < 6 && isDigits(item.TicketNumber, n) {
+		item.TicketNumber = strings.Repeat("0", 6-n) + item.TicketNumber
+	}
+
+	var err error
+	if item.TicketQuantity, err = parseImportInt(cell("ticketQuantity")); err != nil {
+		return item, errors.New("จำนวนที่ซื้อต้องเป็นตัวเลข")
+	}
+	if item.TicketAmount, err = parseImportInt(cell("ticketAmount")); err != nil {
+		return item, errors.New("จำนวนเงินสลากต้องเป็นตัวเลข")
+	}
+	if value := cell("date"); value != "" {
+		if item.Date, err = parseImportDate(value); err != nil {
+			return item, fmt.Errorf("วันที่ซื้อไม่ถูกต้อง: %s", value)
+		}
+	}
+	if value := cell("prizeDate"); value != "" {
+		date, err := parseImportDate(value)
+		if err != nil {
+			return item, fmt.Errorf("งวดวันที่ไม่ถูกต้อง: %s", value)
+		}
+		item.PrizeDate = date.Format("2006-01-02")
+	}
+	return item, nil
+}
+
+func parseImportInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil || f != math.Trunc(f) {
+		return 0, errors.New("not an integer")
+	}
+	return int(f), nil
+}
+
+// parseImportDate รองรับ YYYY-MM-DD, DD/MM/YYYY (ค.ศ. หรือ พ.ศ.), RFC 3339 และเลขลำดับวันของ Excel
+func parseImportDate(value string) (time.Time, error) {
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		if serial < 1 || serial > 2958465 {
+			return time.Time{}, errors.New("invalid excel date")
+		}
+		days := math.Floor(serial)
+		excelEpoch := time.Date(1899, 12, 30, 0, 0, 0, 0, thaiLocation)
+		return excelEpoch.AddDate(0, 0, int(days)), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006"} {
+		t, err := time.ParseInLocation(layout, value, thaiLocation)
+		if err != nil {
+			continue
+		}
+		// ปี พ.ศ.
+		if t.Year() > 2400 {
+			t = t.AddDate(-543, 0, 0)
+		}
+		return t, nil
+	}
+	return time.Time{}, errors.New("invalid date")
+}
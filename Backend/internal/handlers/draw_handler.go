@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+// maxCalendarRange คือช่วงวันที่ยาวที่สุดที่ขอปฏิทินได้ในครั้งเดียว
+const maxCalendarRange = 3 * 366 * 24 * time.Hour
+
+type DrawHandler struct {
+	schedule      *draws.Schedule
+	exceptionRepo *repositories.DrawExceptionRepository
+	auditRepo     *repositories.AuditLogRepository
+}
+
+func NewDrawHandler(schedule *draws.Schedule, exceptionRepo *repositories.DrawExceptionRepository, auditRepo *repositories.AuditLogRepository) *DrawHandler {
+	return &DrawHandler{schedule: schedule, exceptionRepo: exceptionRepo, auditRepo: auditRepo}
+}
+
+// GetNext คืนงวดถัดไป (รวมงวดที่ออกวันนี้)
+func (h *DrawHandler) GetNext(c *gin.Context) {
+	now := time.Now().In(draws.Location)
+	draw, err := h.schedule.Next(c.Request.Context(), now)
+	if errors.Is(err, draws.ErrNoUpcomingDraw) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบงวดถัดไป"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	drawDate, _ := time.ParseInLocation("2006-01-02", draw.Date, draws.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, draws.Location)
+	c.JSON(http.StatusOK, gin.H{
+		"draw":      draw,
+		"daysUntil": int(drawDate.Sub(today).Hours() / 24),
+	})
+}
+
+// GetCalendar คืนปฏิทินงวดระหว่าง from ถึง to (YYYY-MM-DD)
+// ค่าเริ่มต้นคือวันนี้ถึงอีก 12 เดือน
+func (h *DrawHandler) GetCalendar(c *gin.Context) {
+	from := time.Now().In(draws.Location)
+	to := from.AddDate(1, 0, 0)
+	if value := c.Query("from"); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, draws.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ from ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+		from = t
+		if c.Query("to") == "" {
+			to = from.AddDate(1, 0, 0)
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, draws.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ to ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+		to = t
+	}
+	if to.Before(from) || to.Sub(from) > maxCalendarRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ช่วงวันที่ต้องไม่เกิน 3 ปี และ to ต้องไม่ก่อน from"})
+		return
+	}
+
+	calendar, err := h.schedule.Calendar(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, calendar)
+}
+
+// GetExceptions คืนรายการวันยกเว้นทั้งหมด (Admin)
+func (h *DrawHandler) GetExceptions(c *gin.Context) {
+	exceptions, err := h.exceptionRepo.GetAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, exceptions)
+}
+
+// CreateException เพิ่มวันยกเว้น (Admin) movedTo ว่างหมายถึงงดออกรางวัลงวดนั้น
+func (h *DrawHandler) CreateException(c *gin.Context) {
+	var input models.DrawException
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ date ไม่ถูกต้อง (YYYY-MM-DD)"})
+		return
+	}
+	if date.Day() != 1 && date.Day() != 16 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date ต้องเป็นวันออกรางวัลปกติ (วันที่ 1 หรือ 16)"})
+		return
+	}
+	if input.MovedTo != "" {
+		movedTo, err := time.Parse("2006-01-02", input.MovedTo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ movedTo ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+		if diff := movedTo.Sub(date); diff < -28*24*time.Hour || diff > 28*24*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "movedTo ต้องห่างจาก date ไม่เกิน 28 วัน"})
+			return
+		}
+	}
+
+	if err := h.exceptionRepo.Create(c.Request.Context(), &input); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "มีวันยกเว้นของงวดนี้อยู่แล้ว"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordChange(c, h.auditRepo, models.AuditDrawExceptionCreated, models.AuditTargetDrawException, input.ID.Hex(), nil, &input, nil)
+	c.JSON(http.StatusCreated, input)
+}
+
+// DeleteException ลบวันยกเว้น (Admin) งวดนั้นจะกลับไปออกตามวันปกติ
+func (h *DrawHandler) DeleteException(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	deleted, err := h.exceptionRepo.Delete(c.Request.Context(), objectID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Draw exception not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordChange(c, h.auditRepo, models.AuditDrawExceptionDeleted, models.AuditTargetDrawException, deleted.ID.Hex(), deleted, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Draw exception deleted successfully"})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/line"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/repositories"
+	"github.com/user/Lotterich/internal/utils"
+)
+
+// LineHandler links LINE accounts to users through the LINE bot, so personal
+// notifications can be pushed to them
+type LineHandler struct {
+	userRepo        *repositories.UserRepository
+	otpRepo         *repositories.OTPRepository
+	preferencesRepo *repositories.PreferencesRepository
+	bot             notify.Notifier
+	botID           string
+	channelSecret   string
+}
+
+// NewLineHandler creates a new LineHandler. bot is nil when no channel token
+// is configured; linking is then unavailable
+func NewLineHandler(userRepo *repositories.UserRepository, otpRepo *repositories.OTPRepository, preferencesRepo *repositories.PreferencesRepository, bot notify.Notifier, botID, channelSecret string) *LineHandler {
+	return &LineHandler{
+		userRepo:        userRepo,
+		otpRepo:         otpRepo,
+		preferencesRepo: preferencesRepo,
+		bot:             bot,
+		botID:           botID,
+		channelSecret:   channelSecret,
+	}
+}
+
+// CreateLink returns a one-time code, and a link that opens a chat with the bot
+// with "link <code>" typed in. Sending it links that LINE account to the current user
+// POST /api/users/me/line/link
+func (h *LineHandler) CreateLink(c *gin.Context) {
+	if h.bot == nil || h.botID == "" || h.channelSecret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ยังไม่ได้ตั้งค่าบอท LINE"})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	token, err := utils.GenerateOpaqueToken(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link"})
+		return
+	}
+	if err := h.otpRepo.Issue(user.ID.Hex(), models.OTPPurposeLineLink, utils.HashToken(token), models.LineLinkTTL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"link":      line.MessageLink(h.botID, "link "+token),
+		"code":      token,
+		"expiresIn": int(models.LineLinkTTL.Seconds()),
+	})
+}
+
+// Unlink removes the linked LINE account of the current user and turns the
+// LINE channel off (email is turned on if nothing else is left)
+// DELETE /api/users/me/line
+func (h *LineHandler) Unlink(c *gin.Context) {
+	userID := c.GetString("userID")
+	if err := h.userRepo.ClearLineUser(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink LINE"})
+		return
+	}
+	h.setLineChannel(c.Request.Context(), userID, false)
+	c.JSON(http.StatusOK, gin.H{"message": "ยกเลิกการเชื่อมต่อ LINE แล้ว"})
+}
+
+// Webhook receives events from LINE. Requests without a valid signature are
+// rejected; events the bot doesn't use are ignored with 200 so LINE doesn't retry them
+// POST /api/line/webhook
+func (h *LineHandler) Webhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil || !line.VerifySignature(h.channelSecret, body, c.GetHeader(line.SignatureHeader)) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var payload line.Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		c.Status(http.StatusOK)
+		return
+	}
+	ctx := c.Request.Context()
+	for _, event := range payload.Events {
+		if !event.Source.Private() {
+			continue
+		}
+		if event.Type == "unfollow" {
+			h.unfollow(ctx, event.Source.UserID)
+			continue
+		}
+		text := event.Text()
+		if text == "" {
+			continue
+		}
+		reply := "ส่ง link ตามด้วยรหัสจากหน้าโปรไฟล์ของ Lotterich เพื่อเชื่อมบัญชีและรับการแจ้งเตือนทาง LINE"
+		if code, ok := line.ParseLink(text); ok {
+			reply = h.linkUser(ctx, event.Source.UserID, code)
+		}
+		h.reply(ctx, event, reply)
+	}
+	c.Status(http.StatusOK)
+}
+
+// linkUser links the LINE account lineUserID to the account that created the one-time code
+func (h *LineHandler) linkUser(ctx context.Context, lineUserID, code string) string {
+	otp, err := h.otpRepo.FindByCodeHash(models.OTPPurposeLineLink, utils.HashToken(code))
+	if err != nil {
+		return "รหัสนี้หมดอายุหรือถูกใช้ไปแล้ว กรุณาสร้างรหัสใหม่จากหน้าโปรไฟล์"
+	}
+	// ใช้ได้ครั้งเดียว: ถ้าลบไม่ได้แสดงว่ามีคำขออื่นใช้ไปก่อนแล้ว
+	if deleted, err := h.otpRepo.Delete(otp.ID); err != nil || !deleted {
+		return "รหัสนี้หมดอายุหรือถูกใช้ไปแล้ว กรุณาสร้างรหัสใหม่จากหน้าโปรไฟล์"
+	}
+
+	user, err := h.userRepo.FindByID(otp.Email)
+	if err != nil {
+		return "ไม่พบบัญชีผู้ใช้ กรุณาสร้างรหัสใหม่จากหน้าโปรไฟล์"
+	}
+	if err := h.userRepo.SetLineUser(user.ID.Hex(), lineUserID); err != nil {
+		fmt.Printf("Failed to link LINE account: %v\n", err)
+		return "เกิดข้อผิดพลาด กรุณาลองใหม่อีกครั้ง"
+	}
+	h.setLineChannel(ctx, user.ID.Hex(), true)
+	return fmt.Sprintf("เชื่อมบัญชี %s สำเร็จ ✅\nการแจ้งเตือนที่คุณเลือกไว้จะถูกส่งมาที่ LINE นี้ด้วย", user.Email)
+}
+
+// unfollow ยกเลิกการเชื่อมต่อเมื่อผู้ใช้บล็อกบอท เพราะส่งข้อความถึงเขาไม่ได้อีกแล้ว
+// การแจ้งเตือนจะไปที่ช่องทางอื่นของผู้ใช้แทน
+func (h *LineHandler) unfollow(ctx context.Context, lineUserID string) {
+	user, err := h.userRepo.FindByLineUser(lineUserID)
+	if err != nil {
+		return
+	}
+	if err := h.userRepo.ClearLineUser(user.ID.Hex()); err != nil {
+		fmt.Printf("Failed to unlink LINE account: %v\n", err)
+		return
+	}
+	h.setLineChannel(ctx, user.ID.Hex(), false)
+}
+
+// setLineChannel เปิดหรือปิดช่องทาง LINE ในการตั้งค่าของผู้ใช้
+func (h *LineHandler) setLineChannel(ctx context.Context, userID string, enabled bool) {
+	prefs, err := h.preferencesRepo.Get(ctx, userID)
+	if err != nil {
+		fmt.Printf("Failed to fetch preferences: %v\n", err)
+		return
+	}
+	prefs.SetChannel(models.ChannelLine, enabled)
+	if err := h.preferencesRepo.Save(ctx, prefs); err != nil {
+		fmt.Printf("Failed to save preferences: %v\n", err)
+	}
+}
+
+func (h *LineHandler) reply(ctx context.Context, event line.Event, text string) {
+	if h.bot == nil {
+		return
+	}
+	msg := notify.Message{To: event.Source.UserID, Text: text}
+	// LINE redelivers an event that didn't get a 200, so reply only once
+	if event.WebhookEventID != "" {
+		msg.Key = "line-reply:" + event.WebhookEventID
+	}
+	if err := h.bot.Notify(ctx, msg); err != nil {
+		fmt.Printf("Failed to queue LINE reply: %v\n", err)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/user/Lotterich/internal/jobs"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+const (
+	defaultOutboxPageSize = 50
+	maxOutboxPageSize     = 200
+)
+
+// outboxStatuses are the statuses GetOutbox can filter by
+var outboxStatuses = map[string]bool{
+	models.OutboxPending: true,
+	models.OutboxSending: true,
+	models.OutboxSent:    true,
+	models.OutboxDead:    true,
+	models.OutboxExpired: true,
+}
+
+// OutboxHandler lets admins inspect notification deliveries and replay failed ones
+type OutboxHandler struct {
+	outboxRepo *repositories.OutboxRepository
+	outbox     *jobs.Outbox
+	auditRepo  *repositories.AuditLogRepository
+}
+
+// NewOutboxHandler creates a new OutboxHandler
+func NewOutboxHandler(outboxRepo *repositories.OutboxRepository, outbox *jobs.Outbox, auditRepo *repositories.AuditLogRepository) *OutboxHandler {
+	return &OutboxHandler{
+		outboxRepo: outboxRepo,
+		outbox:     outbox,
+		auditRepo:  auditRepo,
+	}
+}
+
+// GetOutbox lists queued notifications, newest first. Message bodies are never returned
+// GET /api/admin/outbox?status=&channel=&page=&limit=
+func (h *OutboxHandler) GetOutbox(c *gin.Context) {
+	search := models.OutboxSearch{
+		Status:  c.Query("status"),
+		Channel: c.Query("channel"),
+	}
+	if search.Status != "" && !outboxStatuses[search.Status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status ไม่ถูกต้อง"})
+		return
+	}
+
+	var err error
+	if search.Page, err = positiveIntQuery(c, "page", 1, 1<<20); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if search.Limit, err = positiveIntQuery(c, "limit", defaultOutboxPageSize, maxOutboxPageSize); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	messages, total, err := h.outboxRepo.Search(c.Request.Context(), search)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch outbox"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": messages,
+		"page":     search.Page,
+		"limit":    search.Limit,
+		"total":    total,
+	})
+}
+
+// ReplayOutbox queues a dead message again with a fresh set of attempts
+// POST /api/admin/outbox/:id/replay
+func (h *OutboxHandler) ReplayOutbox(c *gin.Context) {
+	ctx := c.Request.Context()
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	msg, err := h.outboxRepo.GetByID(ctx, objectID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch message"})
+		return
+	}
+	if msg.Status != models.OutboxDead {
+		c.JSON(http.StatusConflict, gin.H{"error": "ส่งใหม่ได้เฉพาะข้อความที่ส่งไม่สำเร็จ (dead)"})
+		return
+	}
+	if msg.ExpiresAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "ข้อความที่มีวันหมดอายุ (เช่นอีเมล OTP) ส่งใหม่ไม่ได้"})
+		return
+	}
+
+	replayed, err := h.outboxRepo.Replay(ctx, objectID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusConflict, gin.H{"error": "ส่งใหม่ได้เฉพาะข้อความที่ส่งไม่สำเร็จ (dead)"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay message"})
+		return
+	}
+	h.outbox.Wake()
+
+	recordAudit(c, h.auditRepo, models.AuditOutboxReplayed, models.AuditTargetOutbox, objectID.Hex(), map[string]interface{}{
+		"channel":   msg.Channel,
+		"attempts":  msg.Attempts,
+		"lastError": msg.LastError,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "ส่งข้อความใหม่แล้ว", "outbox": replayed})
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+// PreferencesHandler handles the notification preferences of the current user
+type PreferencesHandler struct {
+	preferencesRepo *repositories.PreferencesRepository
+	userRepo        *repositories.UserRepository
+}
+
+// NewPreferencesHandler creates a new PreferencesHandler
+func NewPreferencesHandler(preferencesRepo *repositories.PreferencesRepository, userRepo *repositories.UserRepository) *PreferencesHandler {
+	return &PreferencesHandler{
+		preferencesRepo: preferencesRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// GetPreferences returns the preferences, and which personal chats are linked
+// GET /api/users/me/preferences
+func (h *PreferencesHandler) GetPreferences(c *gin.Context) {
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	prefs, err := h.preferencesRepo.Get(c.Request.Context(), user.ID.Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, preferencesResponse(user, prefs))
+}
+
+// UpdatePreferences changes the fields that were sent
+// PATCH /api/users/me/preferences
+func (h *PreferencesHandler) UpdatePreferences(c *gin.Context) {
+	var input models.UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	ctx := c.Request.Context()
+	prefs, err := h.preferencesRepo.Get(ctx, user.ID.Hex())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch preferences"})
+		return
+	}
+	if err := input.Apply(prefs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.preferencesRepo.Save(ctx, prefs); err != nil {
+		fmt.Printf("Failed to save preferences: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, preferencesResponse(user, prefs))
+}
+
+// preferencesResponse ส่ง linked มาด้วย เพราะช่องทางที่เปิดไว้แต่ยังไม่ได้เชื่อมต่อจะถูกข้าม
+func preferencesResponse(user *models.User, prefs *models.Preferences) gin.H {
+	return gin.H{
+		"preferences": prefs,
+		"linked": gin.H{
+			models.ChannelTelegram: user.TelegramChatID != "",
+			models.ChannelLine:     user.LineUserID != "",
+		},
+	}
+}
@@ -2,27 +2,47 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/user/Lotterich/internal/analytics"
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/export"
+	"github.com/user/Lotterich/internal/jobs"
 	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
 	"github.com/user/Lotterich/internal/repositories"
-	"github.com/user/Lotterich/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type StatisticsHandler struct {
 	repo           *repositories.StatisticsRepository
 	collectionRepo *repositories.CollectionRepository
+	rechecker      *jobs.PrizeRechecker
+	auditRepo      *repositories.AuditLogRepository
+	revisionRepo   *repositories.StatisticsRevisionRepository
+	announcer      notify.Notifier
+	subscribers    *jobs.SubscriberNotifier
 }
 
-func NewStatisticsHandler(repo *repositories.StatisticsRepository, collectionRepo *repositories.CollectionRepository) *StatisticsHandler {
-	return &StatisticsHandler{
+func NewStatisticsHandler(repo *repositories.StatisticsRepository, collectionRepo *repositories.CollectionRepository, rechecker *jobs.PrizeRechecker, auditRepo *repositories.AuditLogRepository, revisionRepo *repositories.StatisticsRevisionRepository, announcer notify.Notifier, subscribers *jobs.SubscriberNotifier) *StatisticsHandler {
+	h := &StatisticsHandler{
 		repo:           repo,
 		collectionRepo: collectionRepo,
+		rechecker:      rechecker,
+		auditRepo:      auditRepo,
+		revisionRepo:   revisionRepo,
+		announcer:      announcer,
+		subscribers:    subscribers,
 	}
+	subscribers.Handle(models.EventNewDraw, h.buildNewDrawMessage)
+	return h
 }
 
 func (h *StatisticsHandler) CreateStatistics(c *gin.Context) {
@@ -31,49 +51,110 @@ func (h *StatisticsHandler) CreateStatistics(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validatePrizeTiers(&stat); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err := h.repo.Create(context.Background(), &stat); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "มีผลรางวัลของงวดวันที่นี้อยู่แล้ว"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.recordRevision(c, models.RevisionCreated, &stat, 0)
+	recordChange(c, h.auditRepo, models.AuditStatisticsCreated, models.AuditTargetStatistics, stat.ID.Hex(), nil, &stat, nil)
 
-	// Format date to Thai format
-	date, err := time.Parse("2006-01-02", stat.Date)
-	if err != nil {
-		fmt.Printf("Error parsing date: %v\n", err)
-		date = time.Now() // Fallback to current date if parsing fails
+	// ตรวจรางวัลสลากของงวดนี้ใหม่ในเบื้องหลัง
+	var recheckJobID string
+	if job, err := h.rechecker.Start(c.Request.Context(), stat.Date, "create"); err != nil {
+		fmt.Printf("Failed to start recheck job: %v\n", err)
+	} else {
+		recheckJobID = job.ID.Hex()
+	}
+
+	// Announce the new draw on every configured channel
+	if err := h.announcer.Notify(c.Request.Context(), newDrawMessage(models.LanguageThai, &stat)); err != nil {
+		// Log error but don't fail the request
+		fmt.Printf("Failed to send new draw notification: %v\n", err)
 	}
+	// and to users who asked for it, in a background job that resumes after a restart
+	if err := h.subscribers.Start(c.Request.Context(), models.EventNewDraw, stat.ID.Hex()); err != nil {
+		fmt.Printf("Failed to start new draw notify job: %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, struct {
+		models.Statistics
+		RecheckJobID string `json:"recheckJobId,omitempty"`
+	}{stat, recheckJobID})
+}
 
-	thaiMonths := []string{
-		"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
-		"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม",
+// newDrawMessage announces the results of a new draw
+func newDrawMessage(lang string, stat *models.Statistics) notify.Message {
+	title := "งวดใหม่ถูกเพิ่มแล้ว!"
+	if lang == models.LanguageEnglish {
+		title = "New draw results are out!"
+	}
+	details := drawDetails(lang, stat)
+	return notify.Message{
+		Subject: title,
+		Text:    "🔔 " + title + "\n\n" + details,
+		HTML:    "🔔 <b>" + title + "</b>\n\n" + details,
+		Key:     "draw-created:" + stat.ID.Hex(),
 	}
+}
 
-	thaiDate := fmt.Sprintf("%d %s %d", date.Day(), thaiMonths[date.Month()-1], date.Year()+543)
+// buildNewDrawMessage builds the new-draw message for the subscribers of the
+// draw job.Ref. If the draw was deleted in the meantime there is nothing to send
+func (h *StatisticsHandler) buildNewDrawMessage(ctx context.Context, job *models.NotifyJob) (jobs.UserMessage, error) {
+	id, err := primitive.ObjectIDFromHex(job.Ref)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	build := func(lang string) notify.Message {
+		msg := newDrawMessage(lang, stat)
+		// HTML เป็นรูปแบบของ Telegram ซึ่งแสดงผลในอีเมลไม่ถูก ส่งเป็นข้อความธรรมดา
+		msg.HTML = ""
+		return msg
+	}
+	// ทุกคนได้ข้อความเดียวกัน
+	return func(ctx context.Context, user *models.User) (func(lang string) notify.Message, error) {
+		return build, nil
+	}, nil
+}
 
-	// Send Telegram notification
-	message := fmt.Sprintf("🔔 <b>งวดใหม่ถูกเพิ่มแล้ว!</b>\n\n"+
-		"📅 งวดวันที่ : %s\n"+
+// drawDetails lists the date and the main prizes of a draw, one per line
+func drawDetails(lang string, stat *models.Statistics) string {
+	if lang == models.LanguageEnglish {
+		return fmt.Sprintf("📅 Draw of : %s\n"+
+			"🏆 1st prize : %s\n"+
+			"🎯 First 3 digits : %s , %s\n"+
+			"🎯 Last 3 digits : %s , %s\n"+
+			"🎯 Last 2 digits : %s",
+			draws.EnglishDate(stat.Date), stat.Prize1,
+			stat.First3One, stat.First3Two,
+			stat.Last3One, stat.Last3Two,
+			stat.Last2)
+	}
+	return fmt.Sprintf("📅 งวดวันที่ : %s\n"+
 		"🏆 รางวัลที่ 1 : %s\n"+
 		"🎯 สามตัวหน้า : %s , %s\n"+
 		"🎯 สามตัวท้าย : %s , %s\n"+
 		"🎯 สองตัวท้าย : %s",
-		thaiDate, stat.Prize1,
+		draws.ThaiDate(stat.Date), stat.Prize1,
 		stat.First3One, stat.First3Two,
 		stat.Last3One, stat.Last3Two,
 		stat.Last2)
-
-	if err := utils.SendTelegramNotification(message); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Failed to send Telegram notification: %v\n", err)
-	}
-
-	c.JSON(http.StatusOK, stat)
 }
 
 func (h *StatisticsHandler) GetAllStatistics(c *gin.Context) {
-	stats, err := h.repo.GetAll(context.Background())
+	stats, err := h.queryStatistics(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	// Debug log
@@ -96,19 +177,28 @@ func (h *StatisticsHandler) DeleteStatistics(c *gin.Context) {
 		return
 	}
 
+	// Keep the result as a revision so the deletion can be rolled back
+	h.ensureBaseline(c, stat)
+
 	// Delete the statistics
 	if err := h.repo.Delete(c.Request.Context(), objectID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.recordRevision(c, models.RevisionDeleted, stat, 0)
+	recordChange(c, h.auditRepo, models.AuditStatisticsDeleted, models.AuditTargetStatistics, id, stat, nil, map[string]interface{}{
+		"date": stat.Date,
+	})
 
-	// Update collection prize fields for the deleted statistics date
-	if err := h.collectionRepo.UpdatePrizeFieldsByDate(c.Request.Context(), stat.Date); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection prize fields"})
-		return
+	// สลากของงวดนี้กลับไปเป็นรอผลในเบื้องหลัง แต่ยังผูกกับวันที่งวดไว้
+	// ถ้าย้อนผลรางวัลกลับมา (rollback) สลากชุดเดิมจะถูกตรวจรางวัลใหม่
+	response := gin.H{"message": "Statistics deleted successfully"}
+	if job, err := h.rechecker.Start(c.Request.Context(), stat.Date, "delete"); err != nil {
+		fmt.Printf("Failed to start recheck job: %v\n", err)
+	} else {
+		response["recheckJobId"] = job.ID.Hex()
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Statistics deleted successfully"})
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *StatisticsHandler) UpdateStatistics(c *gin.Context) {
@@ -124,15 +214,77 @@ func (h *StatisticsHandler) UpdateStatistics(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validatePrizeTiers(&stat); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// ผลรางวัลเดิม เพื่อรู้ว่าวันที่งวดถูกเปลี่ยนหรือไม่
+	previous, err := h.repo.GetByID(c.Request.Context(), objectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Statistics not found"})
+		return
+	}
+	h.ensureBaseline(c, previous)
 	if err := h.repo.Update(context.Background(), objectID, &stat); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "มีผลรางวัลของงวดวันที่นี้อยู่แล้ว"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	stat.ID = objectID
+	h.recordRevision(c, models.RevisionUpdated, &stat, 0)
+	recordChange(c, h.auditRepo, models.AuditStatisticsUpdated, models.AuditTargetStatistics, id, previous, &stat, nil)
+
+	// ตรวจรางวัลสลากของงวดนี้ใหม่ (และงวดเดิมถ้าเปลี่ยนวันที่) ในเบื้องหลัง
+	dates := []string{stat.Date}
+	if previous.Date != stat.Date {
+		dates = append(dates, previous.Date)
+	}
+	recheckJobIDs := []string{}
+	for _, date := range dates {
+		job, err := h.rechecker.Start(c.Request.Context(), date, "update")
+		if err != nil {
+			fmt.Printf("Failed to start recheck job: %v\n", err)
+			continue
+		}
+		recheckJobIDs = append(recheckJobIDs, job.ID.Hex())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Statistics updated successfully",
+		"recheckJobIds": recheckJobIDs,
+	})
+}
+
+// GetRecheckJobs คืนงานตรวจรางวัลซ้ำล่าสุด กรองตามงวดด้วย ?date=YYYY-MM-DD
+func (h *StatisticsHandler) GetRecheckJobs(c *gin.Context) {
+	recheckJobs, err := h.rechecker.List(c.Request.Context(), c.Query("date"), 20)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Statistics updated successfully"})
+	c.JSON(http.StatusOK, recheckJobs)
+}
+
+// GetRecheckJob คืนความคืบหน้าและผลนับของงานตรวจรางวัลซ้ำ
+func (h *StatisticsHandler) GetRecheckJob(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	job, err := h.rechecker.Get(c.Request.Context(), objectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recheck job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
 }
 
 func (h *StatisticsHandler) GetLatestStatistics(c *gin.Context) {
-	stats, err := h.repo.GetAll(context.Background())
+	stats, err := h.repo.GetLatest(c.Request.Context(), 1)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -143,22 +295,234 @@ func (h *StatisticsHandler) GetLatestStatistics(c *gin.Context) {
 		return
 	}
 
-	// Sort by date in descending order and get the latest
-	latestStat := stats[0]
-	for _, stat := range stats {
-		if stat.Date > latestStat.Date {
-			latestStat = stat
+	c.JSON(http.StatusOK, stats[0])
+}
+
+func (h *StatisticsHandler) GetAllStatisticsPublic(c *gin.Context) {
+	stats, err := h.queryStatistics(c)
+	if err != nil {
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+const (
+	defaultAnalyticsDraws = 50
+	maxAnalyticsDraws     = 1000
+	defaultAnalyticsTop   = 10
+	maxAnalyticsTop       = 100
+)
+
+// GetAnalytics วิเคราะห์ความถี่ของเลขที่ออกรางวัล
+// query: draws=N วิเคราะห์ N งวดล่าสุด (ค่าเริ่มต้น 50) หรือ from/to ช่วงวันที่ (YYYY-MM-DD)
+// และ top=N จำนวนอันดับใน hot, cold และ streaks (ค่าเริ่มต้น 10)
+func (h *StatisticsHandler) GetAnalytics(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	top, err := positiveIntQuery(c, "top", defaultAnalyticsTop, maxAnalyticsTop)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var draws []models.Statistics
+	from, to := c.Query("from"), c.Query("to")
+	if from != "" || to != "" {
+		for _, d := range []string{from, to} {
+			if _, err := time.Parse("2006-01-02", d); d != "" && err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+				return
+			}
 		}
+		draws, err = h.repo.GetByDateRange(ctx, from, to)
+	} else {
+		n, parseErr := positiveIntQuery(c, "draws", defaultAnalyticsDraws, maxAnalyticsDraws)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error()})
+			return
+		}
+		draws, err = h.repo.GetLatest(ctx, int64(n))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, latestStat)
+	c.JSON(http.StatusOK, analytics.Analyze(draws, top))
 }
 
-func (h *StatisticsHandler) GetAllStatisticsPublic(c *gin.Context) {
-	stats, err := h.repo.GetAll(context.Background())
+// positiveIntQuery อ่านจำนวนเต็มบวกจาก query ถ้าไม่ระบุใช้ def และไม่เกิน max
+func positiveIntQuery(c *gin.Context, key string, def, max int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s ต้องเป็นจำนวนเต็มบวก", key)
+	}
+	if n > max {
+		n = max
+	}
+	return n, nil
+}
+
+const maxLatestStatistics = 100
+
+// queryStatistics ดึงผลรางวัลตาม query ของรายการผลรางวัล
+// date=YYYY-MM-DD งวดเดียว, latest=N งวดล่าสุด N งวด, from/to ช่วงวันที่ (YYYY-MM-DD)
+// ถ้าไม่ระบุจะคืนทุกงวด ถ้าเกิดข้อผิดพลาดจะตอบ error กลับไปแล้ว
+func (h *StatisticsHandler) queryStatistics(c *gin.Context) ([]models.Statistics, error) {
+	ctx := c.Request.Context()
+	date, latest, from, to := c.Query("date"), c.Query("latest"), c.Query("from"), c.Query("to")
+
+	for _, d := range []string{date, from, to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return nil, err
+		}
+	}
+
+	var stats []models.Statistics
+	var err error
+	switch {
+	case date != "":
+		stats = []models.Statistics{}
+		stat, findErr := h.repo.GetByDate(ctx, date)
+		if findErr == nil {
+			stats = append(stats, *stat)
+		} else if !errors.Is(findErr, mongo.ErrNoDocuments) {
+			err = findErr
+		}
+	case latest != "":
+		n, parseErr := strconv.ParseInt(latest, 10, 64)
+		if parseErr != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "latest ต้องเป็นจำนวนเต็มบวก"})
+			return nil, errors.New("invalid latest")
+		}
+		if n > maxLatestStatistics {
+			n = maxLatestStatistics
+		}
+		stats, err = h.repo.GetLatest(ctx, n)
+	case from != "" || to != "":
+		stats, err = h.repo.GetByDateRange(ctx, from, to)
+	default:
+		stats, err = h.repo.GetAll(ctx)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, err
+	}
+	return stats, nil
+}
+
+// validatePrizeTiers ตรวจสอบเลขรางวัลที่ 2 - 5
+// แต่ละรางวัลจะเว้นว่างไว้ได้ (งวดเก่าที่ไม่ได้บันทึก) แต่ถ้ากรอกต้องครบตามจำนวนรางวัลและเป็นเลข 6 หลัก
+func validatePrizeTiers(stat *models.Statistics) error {
+	for _, tier := range []struct {
+		label   string
+		numbers []string
+		count   int
+	}{
+		{"รางวัลที่ 2", stat.Prize2, models.Prize2Count},
+		{"รางวัลที่ 3", stat.Prize3, models.Prize3Count},
+		{"รางวัลที่ 4", stat.Prize4, models.Prize4Count},
+		{"รางวัลที่ 5", stat.Prize5, models.Prize5Count},
+	} {
+		if len(tier.numbers) == 0 {
+			continue
+		}
+		if len(tier.numbers) != tier.count {
+			return fmt.Errorf("%s ต้องมี %d รางวัล", tier.label, tier.count)
+		}
+		for _, number := range tier.numbers {
+			if !isDigits(number, 6) {
+				return fmt.Errorf("%s ต้องเป็นเลข 6 หลัก: %q", tier.label, number)
+			}
+		}
+	}
+	return nil
+}
+
+func isDigits(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var statisticsExportColumns = []string{
+	"Draw Date", "Prize 1", "First 3", "Last 3", "Last 2",
+	"Prize 2", "Prize 3", "Prize 4", "Prize 5",
+}
+
+// ExportStatistics ส่งออกประวัติผลรางวัลเป็น CSV, XLSX หรือ PDF (?format=)
+// ระบุช่วงวันที่ได้ด้วย from/to (YYYY-MM-DD) ถ้าไม่ระบุส่งออกทุกงวด
+func (h *StatisticsHandler) ExportStatistics(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	from, to := c.Query("from"), c.Query("to")
+	for _, d := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", d); d != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	cursor, err := h.repo.FindByDateRange(ctx, from, to)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, stats)
+	defer cursor.Close(ctx)
+
+	w, err := export.NewWriter(format, c.Writer, "Lotterich Draw Results")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format ต้องเป็น csv, xlsx หรือ pdf"})
+		return
+	}
+	setExportHeaders(c, "lotterich-statistics", format)
+
+	// อ่านทีละงวดจาก cursor แล้วเขียนออกไปเลย ไม่โหลดทุกงวดไว้ในหน่วยความจำ
+	count := 0
+	err = w.WriteHeader(statisticsExportColumns)
+	for err == nil && cursor.Next(ctx) {
+		var stat models.Statistics
+		if err = cursor.Decode(&stat); err != nil {
+			break
+		}
+		count++
+		err = w.WriteRow([]string{
+			stat.Date,
+			stat.Prize1,
+			strings.TrimSpace(stat.First3One + " " + stat.First3Two),
+			strings.TrimSpace(stat.Last3One + " " + stat.Last3Two),
+			stat.Last2,
+			strings.Join(stat.Prize2, " "),
+			strings.Join(stat.Prize3, " "),
+			strings.Join(stat.Prize4, " "),
+			strings.Join(stat.Prize5, " "),
+		})
+	}
+	if err == nil {
+		err = cursor.Err()
+	}
+	if err == nil {
+		err = w.WriteSummary([][2]string{{"Total Draws", strconv.Itoa(count)}})
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Printf("Failed to export statistics: %v\n", err)
+		c.Abort()
+	}
 }
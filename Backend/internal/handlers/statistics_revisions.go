@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/user/Lotterich/internal/models"
+)
+
+// GetRevisions คืนทุกเวอร์ชันของผลรางวัล เวอร์ชันล่าสุดก่อน (รวมผลรางวัลที่ถูกลบไปแล้ว)
+// GET /api/admin/statistics/:id/revisions
+func (h *StatisticsHandler) GetRevisions(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	revisions, err := h.revisionRepo.FindByStatistics(c.Request.Context(), objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// RollbackStatistics ย้อนผลรางวัลกลับไปเป็นเวอร์ชันที่ระบุ (กู้คืนได้แม้ถูกลบไปแล้ว)
+// การย้อนกลับจะบันทึกเป็นเวอร์ชันใหม่ และตรวจรางวัลสลากของงวดที่เกี่ยวข้องใหม่
+// POST /api/admin/statistics/:id/revisions/:version/rollback
+func (h *StatisticsHandler) RollbackStatistics(c *gin.Context) {
+	ctx := c.Request.Context()
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version ต้องเป็นจำนวนเต็มบวก"})
+		return
+	}
+
+	revision, err := h.revisionRepo.Get(ctx, objectID, version)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ผลรางวัลปัจจุบัน ไม่มีถ้าถูกลบไปแล้ว
+	current, err := h.repo.GetByID(ctx, objectID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	restored := revision.Result
+	restored.ID = objectID
+	if current != nil && diffFields(current, &restored) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ผลรางวัลปัจจุบันตรงกับเวอร์ชันนี้อยู่แล้ว"})
+		return
+	}
+	if err := validatePrizeTiers(&restored); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if current != nil {
+		h.ensureBaseline(c, current)
+	}
+	if err := h.repo.Restore(ctx, &restored); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "มีผลรางวัลของงวดวันที่นี้อยู่แล้ว"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.recordRevision(c, models.RevisionRolledBack, &restored, version)
+	recordChange(c, h.auditRepo, models.AuditStatisticsRolledBack, models.AuditTargetStatistics, objectID.Hex(), current, &restored, map[string]interface{}{
+		"version": version,
+	})
+
+	// ตรวจรางวัลสลากของงวดที่ได้คืนมา และงวดเดิมถ้าวันที่ต่างกัน
+	dates := []string{restored.Date}
+	if current != nil && current.Date != restored.Date {
+		dates = append(dates, current.Date)
+	}
+	recheckJobIDs := []string{}
+	for _, date := range dates {
+		job, err := h.rechecker.Start(ctx, date, "rollback")
+		if err != nil {
+			fmt.Printf("Failed to start recheck job: %v\n", err)
+			continue
+		}
+		recheckJobIDs = append(recheckJobIDs, job.ID.Hex())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       fmt.Sprintf("ย้อนผลรางวัลกลับไปเวอร์ชัน %d เรียบร้อย", version),
+		"statistics":    restored,
+		"recheckJobIds": recheckJobIDs,
+	})
+}
+
+// recordRevision บันทึกผลรางวัล stat เป็นเวอร์ชันใหม่ ถ้าบันทึกไม่ได้จะแค่ log ไว้
+// เพราะตัวผลรางวัลถูกเปลี่ยนไปแล้ว
+func (h *StatisticsHandler) recordRevision(c *gin.Context, action string, stat *models.Statistics, restoredFrom int) {
+	revision := models.StatisticsRevision{
+		StatisticsID: stat.ID,
+		Action:       action,
+		Result:       *stat,
+		RestoredFrom: restoredFrom,
+		ActorID:      c.GetString("userID"),
+		ActorEmail:   c.GetString("userEmail"),
+	}
+	if err := h.revisionRepo.Record(c.Request.Context(), &revision); err != nil {
+		fmt.Printf("Failed to record statistics revision: %v\n", err)
+	}
+}
+
+// ensureBaseline บันทึกสภาพปัจจุบันของผลรางวัลที่สร้างก่อนเริ่มเก็บประวัติ
+// ก่อนที่จะถูกแก้ไขหรือลบ เพื่อให้ย้อนกลับมาได้
+func (h *StatisticsHandler) ensureBaseline(c *gin.Context, stat *models.Statistics) {
+	exists, err := h.revisionRepo.HasAny(c.Request.Context(), stat.ID)
+	if err != nil {
+		fmt.Printf("Failed to check statistics revisions: %v\n", err)
+		return
+	}
+	if !exists {
+		h.recordRevision(c, models.RevisionBaseline, stat, 0)
+	}
+}
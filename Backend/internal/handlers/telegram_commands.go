@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/prize"
+	"github.com/user/Lotterich/internal/repositories"
+	"github.com/user/Lotterich/internal/telegram"
+)
+
+// myTicketsLimit คือจำนวนสลากล่าสุดที่ /mytickets แสดง
+const myTicketsLimit = 10
+
+var ticketNumberPattern = regexp.MustCompile(`^\d{6}$`)
+
+const notLinkedReply = "แชทนี้ยังไม่ได้เชื่อมกับบัญชี Lotterich\n" +
+	"สร้างรหัสได้จากหน้าโปรไฟล์บนเว็บไซต์ แล้วส่ง /link <รหัส> มาที่นี่"
+
+const suspendedReply = "บัญชีนี้ถูกระงับการใช้งาน"
+
+// newCommandRouter registers the bot commands
+func (h *TelegramHandler) newCommandRouter() *telegram.Router {
+	r := telegram.NewRouter()
+	r.Handle("start", "", "", h.startCommand)
+	r.Handle("check", "123456 [YYYY-MM-DD]", "ตรวจเลขกับงวดล่าสุดหรืองวดที่ระบุ", h.checkCommand)
+	r.Handle("latest", "", "ผลรางวัลงวดล่าสุด", h.latestCommand)
+	r.Handle("mytickets", "", "สลากล่าสุดของฉัน", h.myTicketsCommand)
+	r.Handle("subscribe", "", "รับแจ้งผลรางวัลทุกงวดที่แชทนี้", h.subscribeCommand)
+	r.Handle("unsubscribe", "", "เลิกรับแจ้งผลรางวัลทุกงวด", h.unsubscribeCommand)
+	r.Handle("link", "<รหัส>", "เชื่อมแชทนี้กับบัญชี Lotterich", h.linkCommand)
+	return r
+}
+
+// /start [code] - the deep link from the profile page sends the link code
+func (h *TelegramHandler) startCommand(ctx context.Context, msg *telegram.Message, args []string) string {
+	if len(args) > 0 {
+		return h.linkChat(ctx, msg, args[0])
+	}
+	return "สวัสดีครับ 👋 บอทตรวจหวย Lotterich\n\n" + h.commands.Help()
+}
+
+// /link <code>
+func (h *TelegramHandler) linkCommand(ctx context.Context, msg *telegram.Message, args []string) string {
+	if len(args) == 0 {
+		return "กรุณาระบุรหัส เช่น /link ABCD1234\nสร้างรหัสได้จากหน้าโปรไฟล์บนเว็บไซต์"
+	}
+	return h.linkChat(ctx, msg, args[0])
+}
+
+// /check 123456 [YYYY-MM-DD]
+func (h *TelegramHandler) checkCommand(ctx context.Context, msg *telegram.Message, args []string) string {
+	if len(args) == 0 || !ticketNumberPattern.MatchString(args[0]) {
+		return "กรุณาระบุเลขสลาก 6 หลัก เช่น /check 123456 หรือ /check 123456 2026-10-16"
+	}
+	date := ""
+	if len(args) > 1 {
+		if _, err := time.Parse("2006-01-02", args[1]); err != nil {
+			return "วันที่ต้องอยู่ในรูปแบบ YYYY-MM-DD เช่น 2026-10-16"
+		}
+		date = args[1]
+	}
+
+	stat, reply := h.findDraw(ctx, date)
+	if stat == nil {
+		return reply
+	}
+	result, err := h.prizeEngine.Check(args[0], stat)
+	if err != nil {
+		return "ตรวจรางวัลงวดนี้ไม่ได้ กรุณาลองใหม่ภายหลัง"
+	}
+
+	header := fmt.Sprintf("🎫 %s\n📅 งวดวันที่ %s\n\n", args[0], draws.ThaiDate(stat.Date))
+	if !result.Won() {
+		return header + "ไม่ถูกรางวัล 😢"
+	}
+	return header + fmt.Sprintf("🎉 ถูก%s\n💰 %s บาท",
+		prizeLabels(result.Types), prize.FormatBaht(result.Amount))
+}
+
+// /latest
+func (h *TelegramHandler) latestCommand(ctx context.Context, msg *telegram.Message, args []string) string {
+	stat, reply := h.findDraw(ctx, "")
+	if stat == nil {
+		return reply
+	}
+	return "🔔 ผลรางวัลงวดล่าสุด\n\n" + drawDetails(models.LanguageThai, stat)
+}
+
+// /mytickets - the latest tickets of the linked account and their results
+func (h *TelegramHandler) myTicketsCommand(ctx context.Context, msg *telegram.Message, args []string) string {
+	if !msg.Private() {
+		return "ดูสลากของคุณได้ในแชทส่วนตัวกับบอทเท่านั้น"
+	}
+	user, reply := h.activeUser(msg)
+	if user == nil {
+		return reply
+	}
+	tickets, _, err := h.collectionRepo.FindPage(ctx, user.Email, repositories.CollectionQuery{
+		SortBy: repositories.SortByDrawDate,
+		Limit:  myTicketsLimit,
+	})
+	if err != nil {
+		return "เกิดข้อผิดพลาด กรุณาลองใหม่อีกครั้ง"
+	}
+	if len(tickets) == 0 {
+		return "ยังไม่มีสลากในบัญชีของคุณ"
+	}
+	total, err := h.collectionRepo.CountByEmail(ctx, user.Email)
+	if err != nil {
+		return "เกิดข้อผิดพลาด กรุณาลองใหม่อีกครั้ง"
+	}
+
+	lines := []string{fmt.Sprintf("🎫 สลากของคุณ (%d รายการล่าสุดจาก %d)", len(tickets), total)}
+	for _, t := range tickets {
+		status := "⏳ รอผล"
+		switch {
+		case len(t.PrizeTypes) > 0:
+			status = fmt.Sprintf("🎉 %s %s บาท", prizeLabels(t.PrizeTypes), prize.FormatBaht(t.PrizeAmount*t.TicketQuantity))
+		case t.PrizeType != "":
+			status = "ไม่ถูกรางวัล"
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d ใบ) งวด %s : %s",
+			t.TicketNumber, t.TicketQuantity, draws.ThaiDate(t.PrizeDate), status))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// /subscribe - turn on new draw notifications and the Telegram channel
+func (h *TelegramHandler) subscribeCommand(ctx context.Context, msg *telegram.Message, args []string) string {
+	return h.setNewDrawSubscription(ctx, msg, true)
+}
+
+// /unsubscribe
+func (h *TelegramHandler) unsubscribeCommand(ctx context.Context, msg *telegram.Message, args []string) string {
+	return h.setNewDrawSubscription(ctx, msg, false)
+}
+
+// setNewDrawSubscription ยอมให้บัญชีที่ถูกระงับเลิกรับแจ้งได้ แต่สมัครรับเพิ่มไม่ได้
+func (h *TelegramHandler) setNewDrawSubscription(ctx context.Context, msg *telegram.Message, subscribe bool) string {
+	user, err := h.userRepo.FindByTelegramChat(msg.ChatID())
+	if err != nil {
+		return notLinkedReply
+	}
+	if subscribe && user.Suspended {
+		return suspendedReply
+	}
+	prefs, err := h.preferencesRepo.Get(ctx, user.ID.Hex())
+	if err != nil {
+		return "เกิดข้อผิดพลาด กรุณาลองใหม่อีกครั้ง"
+	}
+	prefs.Events.NewDraw = subscribe
+	if subscribe {
+		prefs.Channels.Telegram = true
+	}
+	if err := h.preferencesRepo.Save(ctx, prefs); err != nil {
+		fmt.Printf("Failed to save preferences: %v\n", err)
+		return "เกิดข้อผิดพลาด กรุณาลองใหม่อีกครั้ง"
+	}
+	if subscribe {
+		return "✅ จะแจ้งผลรางวัลทุกงวดที่แชทนี้ เลิกรับได้ด้วย /unsubscribe"
+	}
+	return "เลิกแจ้งผลรางวัลทุกงวดแล้ว"
+}
+
+// activeUser returns the account linked to the chat of msg. When the chat
+// isn't linked or the account is suspended, it returns the reply to send instead
+func (h *TelegramHandler) activeUser(msg *telegram.Message) (*models.User, string) {
+	user, err := h.userRepo.FindByTelegramChat(msg.ChatID())
+	if err != nil {
+		return nil, notLinkedReply
+	}
+	if user.Suspended {
+		return nil, suspendedReply
+	}
+	return user, ""
+}
+
+// findDraw returns the draw of date, or the latest one when date is empty.
+// When there is none, it returns the reply to send instead
+func (h *TelegramHandler) findDraw(ctx context.Context, date string) (*models.Statistics, string) {
+	if date != "" {
+		stat, err := h.statisticsRepo.GetByDate(ctx, date)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, "ยังไม่มีผลรางวัลงวดวันที่ " + draws.ThaiDate(date)
+		}
+		if err != nil {
+			return nil, "เกิดข้อผิดพลาด กรุณาลองใหม่อีกครั้ง"
+		}
+		return stat, ""
+	}
+
+	stats, err := h.statisticsRepo.GetLatest(ctx, 1)
+	if err != nil {
+		return nil, "เกิดข้อผิดพลาด กรุณาลองใหม่อีกครั้ง"
+	}
+	if len(stats) == 0 {
+		return nil, "ยังไม่มีผลรางวัล"
+	}
+	return &stats[0], ""
+}
+
+// prizeLabels joins the Thai names of prize types
+func prizeLabels(types []string) string {
+	labels := make([]string, len(types))
+	for i, t := range types {
+		labels[i] = prize.Labels[t]
+		if labels[i] == "" {
+			labels[i] = t
+		}
+	}
+	return strings.Join(labels, ", ")
+}
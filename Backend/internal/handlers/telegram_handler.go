@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/prize"
+	"github.com/user/Lotterich/internal/repositories"
+	"github.com/user/Lotterich/internal/telegram"
+	"github.com/user/Lotterich/internal/utils"
+)
+
+// TelegramHandler links Telegram chats to accounts and answers bot commands
+// sent through the webhook (see telegram_commands.go)
+type TelegramHandler struct {
+	userRepo        *repositories.UserRepository
+	otpRepo         *repositories.OTPRepository
+	preferencesRepo *repositories.PreferencesRepository
+	statisticsRepo  *repositories.StatisticsRepository
+	collectionRepo  *repositories.CollectionRepository
+	prizeEngine     *prize.Engine
+	bot             notify.Notifier
+	botUsername     string
+	webhookSecret   string
+	commands        *telegram.Router
+}
+
+// NewTelegramHandler creates a new TelegramHandler. bot is nil when no bot token
+// is configured; linking is then unavailable
+func NewTelegramHandler(userRepo *repositories.UserRepository, otpRepo *repositories.OTPRepository, preferencesRepo *repositories.PreferencesRepository, statisticsRepo *repositories.StatisticsRepository, collectionRepo *repositories.CollectionRepository, prizeEngine *prize.Engine, bot notify.Notifier, botUsername, webhookSecret string) *TelegramHandler {
+	h := &TelegramHandler{
+		userRepo:        userRepo,
+		otpRepo:         otpRepo,
+		preferencesRepo: preferencesRepo,
+		statisticsRepo:  statisticsRepo,
+		collectionRepo:  collectionRepo,
+		prizeEngine:     prizeEngine,
+		bot:             bot,
+		botUsername:     botUsername,
+		webhookSecret:   webhookSecret,
+	}
+	h.commands = h.newCommandRouter()
+	return h
+}
+
+// CreateLink returns a one-time deep link that links the chat which opens it
+// to the current user. The same code also works with "/link <code>"
+// POST /api/users/me/telegram/link
+func (h *TelegramHandler) CreateLink(c *gin.Context) {
+	if h.bot == nil || h.botUsername == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ยังไม่ได้ตั้งค่าบอท Telegram"})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	token, err := utils.GenerateOpaqueToken(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link"})
+		return
+	}
+	// อีเมลใน access token อาจเก่าแล้ว (เปลี่ยนอีเมลจากอีกอุปกรณ์) จึงผูกรหัสกับ ID ของผู้ใช้
+	if err := h.otpRepo.Issue(user.ID.Hex(), models.OTPPurposeTelegramLink, utils.HashToken(token), models.TelegramLinkTTL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"link":      telegram.DeepLink(h.botUsername, token),
+		"code":      token,
+		"expiresIn": int(models.TelegramLinkTTL.Seconds()),
+	})
+}
+
+// Unlink removes the linked Telegram chat of the current user and turns the
+// Telegram channel off (email is turned on if nothing else is left)
+// DELETE /api/users/me/telegram
+func (h *TelegramHandler) Unlink(c *gin.Context) {
+	userID := c.GetString("userID")
+	if err := h.userRepo.ClearTelegramChat(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink Telegram"})
+		return
+	}
+	h.setTelegramChannel(c.Request.Context(), userID, false)
+	c.JSON(http.StatusOK, gin.H{"message": "ยกเลิกการเชื่อมต่อ Telegram แล้ว"})
+}
+
+// Webhook receives updates from Telegram
+// Anything that is not a valid update is ignored with 200 so Telegram doesn't retry it
+// POST /api/telegram/webhook
+func (h *TelegramHandler) Webhook(c *gin.Context) {
+	secret := c.GetHeader(telegram.SecretHeader)
+	if h.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(h.webhookSecret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var update telegram.Update
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.Status(http.StatusOK)
+		return
+	}
+	msg := update.Command()
+	if msg == nil {
+		c.Status(http.StatusOK)
+		return
+	}
+	if reply, ok := h.commands.Dispatch(c.Request.Context(), msg); ok && reply != "" {
+		h.reply(c, update, reply)
+	}
+	c.Status(http.StatusOK)
+}
+
+// linkChat links the chat of msg to the account that created the one-time code
+func (h *TelegramHandler) linkChat(ctx context.Context, msg *telegram.Message, code string) string {
+	if !msg.Private() {
+		return "กรุณาเชื่อมบัญชีในแชทส่วนตัวกับบอทเท่านั้น"
+	}
+
+	otp, err := h.otpRepo.FindByCodeHash(models.OTPPurposeTelegramLink, utils.HashToken(code))
+	if err != nil {
+		return "ลิงก์นี้หมดอายุหรือถูกใช้ไปแล้ว กรุณาสร้างลิงก์ใหม่จากหน้าโปรไฟล์"
+	}
+	// ใช้ได้ครั้งเดียว: ถ้าลบไม่ได้แสดงว่ามีคำขออื่นใช้ไปก่อนแล้ว
+	if deleted, err := h.otpRepo.Delete(otp.ID); err != nil || !deleted {
+		return "ลิงก์นี้หมดอายุหรือถูกใช้ไปแล้ว กรุณาสร้างลิงก์ใหม่จากหน้าโปรไฟล์"
+	}
+
+	user, err := h.userRepo.FindByID(otp.Email)
+	if err != nil {
+		return "ไม่พบบัญชีผู้ใช้ กรุณาสร้างลิงก์ใหม่จากหน้าโปรไฟล์"
+	}
+	if err := h.userRepo.SetTelegramChat(user.ID.Hex(), msg.ChatID()); err != nil {
+		fmt.Printf("Failed to link Telegram chat: %v\n", err)
+		return "เกิดข้อผิดพลาด กรุณาลองใหม่อีกครั้ง"
+	}
+	h.setTelegramChannel(ctx, user.ID.Hex(), true)
+	return fmt.Sprintf("เชื่อมบัญชี %s สำเร็จ ✅\nการแจ้งเตือนที่คุณเลือกไว้จะถูกส่งมาที่แชทนี้ด้วย", user.Email)
+}
+
+// setTelegramChannel เปิดหรือปิดช่องทาง Telegram ในการตั้งค่าของผู้ใช้
+// ถ้าปิดแล้วไม่เหลือช่องทางไหนเลยจะเปิดอีเมลแทน
+func (h *TelegramHandler) setTelegramChannel(ctx context.Context, userID string, enabled bool) {
+	prefs, err := h.preferencesRepo.Get(ctx, userID)
+	if err != nil {
+		fmt.Printf("Failed to fetch preferences: %v\n", err)
+		return
+	}
+	prefs.SetChannel(models.ChannelTelegram, enabled)
+	if err := h.preferencesRepo.Save(ctx, prefs); err != nil {
+		fmt.Printf("Failed to save preferences: %v\n", err)
+	}
+}
+
+func (h *TelegramHandler) reply(c *gin.Context, update telegram.Update, text string) {
+	if h.bot == nil {
+		return
+	}
+	err := h.bot.Notify(c.Request.Context(), notify.Message{
+		To:   update.Message.ChatID(),
+		Text: text,
+		// Telegram resends an update until it gets a 200, so reply only once
+		Key: "telegram-reply:" + strconv.FormatInt(update.UpdateID, 10),
+	})
+	if err != nil {
+		fmt.Printf("Failed to queue Telegram reply: %v\n", err)
+	}
+}
@@ -0,0 +1,234 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/repositories"
+	"github.com/user/Lotterich/internal/throttle"
+)
+
+const (
+	// outboxMaxAttempts คือจำนวนครั้งที่ลองส่งก่อนย้ายข้อความไปเป็น dead
+	outboxMaxAttempts = 8
+	// outboxPollInterval คือรอบที่ตรวจหาข้อความที่ถึงเวลาส่งใหม่
+	outboxPollInterval = 5 * time.Second
+	// outboxLease คือเวลาที่ข้อความถูกจองไว้ระหว่างส่ง
+	outboxLease = time.Minute
+	// outboxSendTimeout จำกัดเวลาการส่งแต่ละครั้ง
+	outboxSendTimeout = 30 * time.Second
+)
+
+// outboxBackoff คือเวลารอก่อนส่งใหม่ เริ่ม 30 วินาทีแล้วเพิ่มเท่าตัวทุกครั้งที่ล้มเหลว
+var outboxBackoff = throttle.Policy{BaseDelay: 30 * time.Second, MaxDelay: time.Hour}
+
+// Outbox ส่งการแจ้งเตือนผ่านคิวใน MongoDB แทนการส่งตรงใน request
+// ข้อความจะถูกบันทึกก่อน แล้ว worker ในเบื้องหลังส่งและลองใหม่แบบ exponential backoff
+// จนสำเร็จหรือครบ outboxMaxAttempts ครั้ง (dead) ซึ่ง admin สั่งส่งใหม่ได้
+type Outbox struct {
+	repo     outboxStore
+	channels map[string]notify.Notifier
+
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// outboxStore คือส่วนของ OutboxRepository ที่ Outbox ใช้ แยกไว้ให้ทดสอบได้โดยไม่ต้องมี MongoDB
+type outboxStore interface {
+	Enqueue(ctx context.Context, msg *models.OutboxMessage) (bool, error)
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.OutboxMessage, error)
+	MarkSent(ctx context.Context, id primitive.ObjectID, attempts int) error
+	MarkExpired(ctx context.Context, id primitive.ObjectID) error
+	MarkRetry(ctx context.Context, id primitive.ObjectID, attempts int, next time.Time, lastError string) error
+	MarkDead(ctx context.Context, id primitive.ObjectID, attempts int, lastError string, dropBody bool) error
+}
+
+// NewOutbox สร้าง outbox ที่ส่งผ่าน channels (อ้างถึงด้วย Name() ของแต่ละช่องทาง)
+func NewOutbox(repo *repositories.OutboxRepository, channels ...notify.Notifier) *Outbox {
+	return newOutbox(repo, channels...)
+}
+
+func newOutbox(repo outboxStore, channels ...notify.Notifier) *Outbox {
+	o := &Outbox{
+		repo:     repo,
+		channels: make(map[string]notify.Notifier, len(channels)),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+	for _, n := range channels {
+		o.channels[n.Name()] = n
+	}
+	return o
+}
+
+// Channel คืน Notifier ที่ใส่ข้อความลงคิวของช่องทาง name แทนการส่งทันที
+func (o *Outbox) Channel(name string) notify.Notifier {
+	return &queuedChannel{outbox: o, name: name}
+}
+
+// Has บอกว่ามีช่องทาง name ถูกตั้งค่าไว้หรือไม่
+func (o *Outbox) Has(name string) bool {
+	_, ok := o.channels[name]
+	return ok
+}
+
+// Start เริ่ม worker ในเบื้องหลัง
+func (o *Outbox) Start() {
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		o.run()
+	}()
+}
+
+// Stop หยุด worker และรอให้ข้อความที่กำลังส่งเสร็จ (ใช้ตอนปิดเซิร์ฟเวอร์)
+// ข้อความที่ยังไม่ได้ส่งจะถูกส่งเมื่อเซิร์ฟเวอร์เริ่มใหม่
+func (o *Outbox) Stop() {
+	close(o.stop)
+	o.wg.Wait()
+}
+
+// Wake ให้ worker ตรวจคิวทันทีโดยไม่ต้องรอรอบถัดไป
+func (o *Outbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *Outbox) enqueue(ctx context.Context, channel string, msg notify.Message) error {
+	if _, ok := o.channels[channel]; !ok {
+		return fmt.Errorf("notification channel %q is not configured", channel)
+	}
+	item := models.OutboxMessage{
+		Channel:        channel,
+		IdempotencyKey: idempotencyKey(channel, msg),
+		To:             msg.To,
+		Subject:        msg.Subject,
+		Text:           msg.Text,
+		HTML:           msg.HTML,
+		NextAttemptAt:  msg.NotBefore,
+	}
+	if !msg.ExpiresAt.IsZero() {
+		item.ExpiresAt = &msg.ExpiresAt
+	}
+	if _, err := o.repo.Enqueue(ctx, &item); err != nil {
+		return err
+	}
+	o.Wake()
+	return nil
+}
+
+// idempotencyKey คือคีย์ที่กันการส่งซ้ำของข้อความ ผู้รับเป็นส่วนหนึ่งของคีย์
+// ข้อความเดียวกัน (Key เดียวกัน) ที่ส่งถึงผู้รับหลายคน เช่นผลรางวัลงวดใหม่ จึงถึงทุกคน
+func idempotencyKey(channel string, msg notify.Message) string {
+	if msg.Key == "" {
+		return channel + ":" + primitive.NewObjectID().Hex()
+	}
+	key := channel + ":" + msg.Key
+	if msg.To != "" {
+		key += ":" + msg.To
+	}
+	return key
+}
+
+func (o *Outbox) run() {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		o.drain()
+		select {
+		case <-o.stop:
+			return
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+// drain ส่งข้อความที่ถึงเวลาทั้งหมดทีละข้อความ
+func (o *Outbox) drain() {
+	ctx := context.Background()
+	for {
+		select {
+		case <-o.stop:
+			return
+		default:
+		}
+
+		msg, err := o.repo.ClaimDue(ctx, time.Now(), outboxLease)
+		if err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				log.Printf("Outbox: failed to claim message: %v", err)
+			}
+			return
+		}
+		o.deliver(ctx, msg)
+	}
+}
+
+func (o *Outbox) deliver(ctx context.Context, msg *models.OutboxMessage) {
+	now := time.Now()
+	if msg.Expired(now) {
+		if err := o.repo.MarkExpired(ctx, msg.ID); err != nil {
+			log.Printf("Outbox: failed to update message %s: %v", msg.ID.Hex(), err)
+		}
+		return
+	}
+
+	attempts := msg.Attempts + 1
+	var sendErr error
+	if notifier, ok := o.channels[msg.Channel]; ok {
+		sendCtx, cancel := context.WithTimeout(ctx, outboxSendTimeout)
+		sendErr = notifier.Notify(sendCtx, notify.Message{
+			To:      msg.To,
+			Subject: msg.Subject,
+			Text:    msg.Text,
+			HTML:    msg.HTML,
+		})
+		cancel()
+	} else {
+		sendErr = fmt.Errorf("notification channel %q is not configured", msg.Channel)
+	}
+
+	var err error
+	switch {
+	case sendErr == nil:
+		err = o.repo.MarkSent(ctx, msg.ID, attempts)
+	case attempts >= outboxMaxAttempts:
+		log.Printf("Outbox: giving up on %s message %s after %d attempts: %v", msg.Channel, msg.ID.Hex(), attempts, sendErr)
+		// ข้อความที่มีวันหมดอายุ (เช่น OTP) ส่งใหม่ไม่ได้ จึงไม่เก็บเนื้อความไว้
+		err = o.repo.MarkDead(ctx, msg.ID, attempts, sendErr.Error(), msg.ExpiresAt != nil)
+	default:
+		next := now.Add(outboxBackoff.Delay(attempts))
+		err = o.repo.MarkRetry(ctx, msg.ID, attempts, next, sendErr.Error())
+	}
+	if err != nil {
+		log.Printf("Outbox: failed to update message %s: %v", msg.ID.Hex(), err)
+	}
+}
+
+// queuedChannel คือ Notifier ของช่องทางหนึ่งที่ส่งผ่าน outbox
+type queuedChannel struct {
+	outbox *Outbox
+	name   string
+}
+
+func (q *queuedChannel) Name() string {
+	return q.name
+}
+
+// Notify บันทึกข้อความลงคิว คืน error เฉพาะเมื่อบันทึกไม่ได้ ไม่ใช่เมื่อส่งไม่สำเร็จ
+func (q *queuedChannel) Notify(ctx context.Context, msg notify.Message) error {
+	return q.outbox.enqueue(ctx, q.name, msg)
+}
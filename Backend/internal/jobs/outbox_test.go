@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+)
+
+// memoryOutbox เก็บคิวไว้ในหน่วยความจำ และกันคีย์ซ้ำเหมือน unique index ของ notification_outbox
+type memoryOutbox struct {
+	mu       sync.Mutex
+	messages []*models.OutboxMessage
+}
+
+func (m *memoryOutbox) Enqueue(ctx context.Context, msg *models.OutboxMessage) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.messages {
+		if existing.IdempotencyKey == msg.IdempotencyKey {
+			return false, nil
+		}
+	}
+	msg.ID = primitive.NewObjectID()
+	msg.Status = models.OutboxPending
+	copied := *msg
+	m.messages = append(m.messages, &copied)
+	return true, nil
+}
+
+func (m *memoryOutbox) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.OutboxMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, msg := range m.messages {
+		if msg.Status == models.OutboxPending && !msg.NextAttemptAt.After(now) {
+			msg.Status = models.OutboxSending
+			copied := *msg
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryOutbox) set(id primitive.ObjectID, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, msg := range m.messages {
+		if msg.ID == id {
+			msg.Status = status
+		}
+	}
+	return nil
+}
+
+func (m *memoryOutbox) MarkSent(ctx context.Context, id primitive.ObjectID, attempts int) error {
+	return m.set(id, models.OutboxSent)
+}
+
+func (m *memoryOutbox) MarkExpired(ctx context.Context, id primitive.ObjectID) error {
+	return m.set(id, models.OutboxExpired)
+}
+
+func (m *memoryOutbox) MarkRetry(ctx context.Context, id primitive.ObjectID, attempts int, next time.Time, lastError string) error {
+	return m.set(id, models.OutboxDead) // ไม่ลองใหม่ในการทดสอบ
+}
+
+func (m *memoryOutbox) MarkDead(ctx context.Context, id primitive.ObjectID, attempts int, lastError string, dropBody bool) error {
+	return m.set(id, models.OutboxDead)
+}
+
+// channel is a notify.Recorder under a channel name
+type channel struct {
+	*notify.Recorder
+	name string
+}
+
+func (c channel) Name() string { return c.name }
+
+func recipients(messages []notify.Message) []string {
+	to := make([]string, len(messages))
+	for i, msg := range messages {
+		to[i] = msg.To
+	}
+	sort.Strings(to)
+	return to
+}
+
+func TestOutboxDeliversSameKeyToEveryRecipient(t *testing.T) {
+	ctx := context.Background()
+	telegram := channel{&notify.Recorder{}, models.ChannelTelegram}
+	o := newOutbox(&memoryOutbox{}, telegram)
+	queue := o.Channel(models.ChannelTelegram)
+
+	msg := notify.Message{Text: "งวดใหม่ถูกเพิ่มแล้ว!", Key: "draw-created:abc"}
+	// ประกาศในกลุ่ม (ผู้รับเริ่มต้นของช่องทาง) แล้วส่งถึงผู้ที่สมัครรับสองคน
+	for _, to := range []string{"", "111", "222"} {
+		m := msg
+		m.To = to
+		if err := queue.Notify(ctx, m); err != nil {
+			t.Fatalf("Notify(%q): %v", to, err)
+		}
+	}
+	// ส่งซ้ำถึงคนเดิม (เช่นงานที่ทำต่อหลังเซิร์ฟเวอร์เริ่มใหม่) ต้องไม่ได้สองครั้ง
+	again := msg
+	again.To = "111"
+	if err := queue.Notify(ctx, again); err != nil {
+		t.Fatal(err)
+	}
+
+	o.drain()
+
+	got := recipients(telegram.Messages())
+	want := []string{"", "111", "222"}
+	if len(got) != len(want) {
+		t.Fatalf("delivered to %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("delivered to %q, want %q", got, want)
+		}
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	a := idempotencyKey("email", notify.Message{To: "a@example.com", Key: "k"})
+	b := idempotencyKey("email", notify.Message{To: "b@example.com", Key: "k"})
+	if a == b {
+		t.Errorf("same key %q for different recipients", a)
+	}
+	if a != idempotencyKey("email", notify.Message{To: "a@example.com", Key: "k"}) {
+		t.Error("key is not stable for the same recipient")
+	}
+	if a == idempotencyKey("telegram", notify.Message{To: "a@example.com", Key: "k"}) {
+		t.Error("same key on different channels")
+	}
+	if idempotencyKey("email", notify.Message{}) == idempotencyKey("email", notify.Message{}) {
+		t.Error("messages without a Key were deduplicated")
+	}
+}
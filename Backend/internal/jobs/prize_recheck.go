@@ -0,0 +1,231 @@
+// Package jobs รวมงานเบื้องหลังที่ทำงานนอก request ของ HTTP
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/prize"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+// recheckBatchSize คือจำนวนสลากที่เขียนผลลงฐานข้อมูลต่อหนึ่งรอบ (และบันทึกความคืบหน้าหนึ่งครั้ง)
+const recheckBatchSize = 200
+
+// PrizeRechecker ตรวจรางวัลสลากทุกใบของงวดใหม่ในเบื้องหลัง
+// งานของงวดเดียวกันจะทำทีละงาน และแต่ละงานจะอ่านผลรางวัลล่าสุดของงวดตอนเริ่มทำ
+// ดังนั้นงานที่ทำเสร็จหลังสุดจะตรงกับผลรางวัลปัจจุบันเสมอ
+type PrizeRechecker struct {
+	collectionRepo recheckCollections
+	statisticsRepo recheckDraws
+	jobRepo        recheckJobStore
+	prizeEngine    *prize.Engine
+	winNotifier    *WinNotifier
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // prize_date -> lock
+	wg    sync.WaitGroup
+}
+
+// ส่วนของ repository ที่ PrizeRechecker ใช้ แยกไว้ให้ทดสอบได้โดยไม่ต้องมี MongoDB
+type recheckCollections interface {
+	CountByPrizeDate(ctx context.Context, date string) (int64, error)
+	FindByPrizeDate(ctx context.Context, date string) (*mongo.Cursor, error)
+	UpdatePrizeResults(ctx context.Context, items []models.Collection) error
+}
+
+type recheckDraws interface {
+	GetByDate(ctx context.Context, date string) (*models.Statistics, error)
+}
+
+type recheckJobStore interface {
+	Create(ctx context.Context, job *models.RecheckJob) error
+	Save(ctx context.Context, job *models.RecheckJob) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.RecheckJob, error)
+	FindRecent(ctx context.Context, prizeDate string, limit int64) ([]models.RecheckJob, error)
+}
+
+func NewPrizeRechecker(collectionRepo *repositories.CollectionRepository, statisticsRepo *repositories.StatisticsRepository, jobRepo *repositories.RecheckJobRepository, prizeEngine *prize.Engine, winNotifier *WinNotifier) *PrizeRechecker {
+	return &PrizeRechecker{
+		collectionRepo: collectionRepo,
+		statisticsRepo: statisticsRepo,
+		jobRepo:        jobRepo,
+		prizeEngine:    prizeEngine,
+		winNotifier:    winNotifier,
+		locks:          make(map[string]*sync.Mutex),
+	}
+}
+
+// Start สร้างงานตรวจรางวัลซ้ำของงวด prizeDate แล้วเริ่มทำในเบื้องหลัง
+func (r *PrizeRechecker) Start(ctx context.Context, prizeDate, trigger string) (*models.RecheckJob, error) {
+	job := &models.RecheckJob{
+		PrizeDate: prizeDate,
+		Trigger:   trigger,
+		Status:    models.RecheckStatusQueued,
+		Outcomes:  models.RecheckOutcomes{PrizeTypes: map[string]int64{}},
+	}
+	if err := r.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	r.wg.Add(1)
+	go func(job models.RecheckJob) {
+		defer r.wg.Done()
+		r.run(&job)
+	}(*job)
+
+	return job, nil
+}
+
+// Get คืนสถานะงานตาม id
+func (r *PrizeRechecker) Get(ctx context.Context, id primitive.ObjectID) (*models.RecheckJob, error) {
+	return r.jobRepo.GetByID(ctx, id)
+}
+
+// List คืนงานล่าสุด กรองตามงวดถ้าระบุ prizeDate
+func (r *PrizeRechecker) List(ctx context.Context, prizeDate string, limit int64) ([]models.RecheckJob, error) {
+	return r.jobRepo.FindRecent(ctx, prizeDate, limit)
+}
+
+// Wait รอจนงานที่กำลังทำอยู่ทั้งหมดเสร็จ (ใช้ตอนปิดเซิร์ฟเวอร์)
+func (r *PrizeRechecker) Wait() {
+	r.wg.Wait()
+}
+
+func (r *PrizeRechecker) lockFor(prizeDate string) *sync.Mutex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lock, ok := r.locks[prizeDate]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.locks[prizeDate] = lock
+	}
+	return lock
+}
+
+func (r *PrizeRechecker) run(job *models.RecheckJob) {
+	lock := r.lockFor(job.PrizeDate)
+	lock.Lock()
+	defer lock.Unlock()
+
+	ctx := context.Background()
+	now := time.Now()
+	job.Status = models.RecheckStatusRunning
+	job.StartedAt = &now
+	r.save(ctx, job)
+
+	err := r.recheck(ctx, job)
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		log.Printf("Recheck job %s for %s failed: %v", job.ID.Hex(), job.PrizeDate, err)
+		job.Status = models.RecheckStatusFailed
+		job.Error = err.Error()
+	} else {
+		log.Printf("Recheck job %s for %s completed: %d tickets", job.ID.Hex(), job.PrizeDate, job.Processed)
+		job.Status = models.RecheckStatusCompleted
+	}
+	r.save(ctx, job)
+
+	// แจ้งเจ้าของสลากที่ถูกรางวัล (ผลงวดที่ถูกลบหรือย้ายวันที่ไม่มีใครถูกรางวัล)
+	if err == nil && job.Outcomes.Win > 0 && r.winNotifier != nil {
+		if err := r.winNotifier.NotifyDraw(ctx, job.PrizeDate); err != nil {
+			log.Printf("Failed to send win notifications for %s: %v", job.PrizeDate, err)
+		}
+	}
+}
+
+func (r *PrizeRechecker) recheck(ctx context.Context, job *models.RecheckJob) error {
+	// ผลรางวัลงวดนี้ ถ้าไม่มี (ถูกลบหรือเปลี่ยนวันที่) สลากของงวดนี้จะกลับไปเป็นรอผล
+	// โดยยังคง prize_date ไว้ ผลรางวัลที่ได้คืนมาภายหลังจึงตรวจสลากชุดเดิมได้
+	var stat *models.Statistics
+	found, err := r.statisticsRepo.GetByDate(ctx, job.PrizeDate)
+	if err == nil {
+		stat = found
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	total, err := r.collectionRepo.CountByPrizeDate(ctx, job.PrizeDate)
+	if err != nil {
+		return err
+	}
+	job.Total = total
+	r.save(ctx, job)
+
+	cursor, err := r.collectionRepo.FindByPrizeDate(ctx, job.PrizeDate)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	batch := make([]models.Collection, 0, recheckBatchSize)
+	flush := func() {
+		if err := r.collectionRepo.UpdatePrizeResults(ctx, batch); err != nil {
+			job.Outcomes.Failed += int64(len(batch))
+		} else {
+			for _, item := range batch {
+				r.count(job, item)
+			}
+		}
+		job.Processed += int64(len(batch))
+		batch = batch[:0]
+		r.save(ctx, job)
+	}
+
+	for cursor.Next(ctx) {
+		var item models.Collection
+		if err := cursor.Decode(&item); err != nil {
+			job.Outcomes.Failed++
+			job.Processed++
+			continue
+		}
+		item.PrizeType = ""
+		item.PrizeTypes = []string{}
+		item.PrizeAmount = 0
+		if stat != nil {
+			result, err := r.prizeEngine.Check(item.TicketNumber, stat)
+			if err != nil {
+				return err
+			}
+			result.ApplyTo(&item)
+		}
+		batch = append(batch, item)
+		if len(batch) == recheckBatchSize {
+			flush()
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
+
+func (r *PrizeRechecker) count(job *models.RecheckJob, item models.Collection) {
+	switch {
+	case item.PrizeType == "":
+		job.Outcomes.Pending++
+	case item.PrizeType == "lose":
+		job.Outcomes.Lose++
+	default:
+		job.Outcomes.Win++
+		for _, prizeType := range item.PrizeTypes {
+			job.Outcomes.PrizeTypes[prizeType]++
+		}
+	}
+}
+
+func (r *PrizeRechecker) save(ctx context.Context, job *models.RecheckJob) {
+	if err := r.jobRepo.Save(ctx, job); err != nil {
+		log.Printf("Failed to save recheck job %s: %v", job.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,205 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/prize"
+)
+
+// memoryCollections เขียนผลรางวัลเฉพาะช่องเดียวกับ CollectionRepository.UpdatePrizeResults
+type memoryCollections struct {
+	mu    sync.Mutex
+	items []models.Collection
+}
+
+func (m *memoryCollections) CountByPrizeDate(ctx context.Context, date string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, item := range m.items {
+		if item.PrizeDate == date {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryCollections) FindByPrizeDate(ctx context.Context, date string) (*mongo.Cursor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	docs := []interface{}{}
+	for _, item := range m.items {
+		if item.PrizeDate == date {
+			docs = append(docs, item)
+		}
+	}
+	return mongo.NewCursorFromDocuments(docs, nil, nil)
+}
+
+func (m *memoryCollections) UpdatePrizeResults(ctx context.Context, items []models.Collection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, updated := range items {
+		for i := range m.items {
+			if m.items[i].ID == updated.ID {
+				m.items[i].PrizeType = updated.PrizeType
+				m.items[i].PrizeTypes = updated.PrizeTypes
+				m.items[i].PrizeAmount = updated.PrizeAmount
+			}
+		}
+	}
+	return nil
+}
+
+func (m *memoryCollections) get(id primitive.ObjectID) models.Collection {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range m.items {
+		if item.ID == id {
+			return item
+		}
+	}
+	return models.Collection{}
+}
+
+type memoryDraws struct {
+	mu    sync.Mutex
+	draws map[string]*models.Statistics
+}
+
+func (m *memoryDraws) GetByDate(ctx context.Context, date string) (*models.Statistics, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stat, ok := m.draws[date]; ok {
+		copied := *stat
+		return &copied, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryDraws) put(stat *models.Statistics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.draws[stat.Date] = stat
+}
+
+func (m *memoryDraws) delete(date string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.draws, date)
+}
+
+type memoryRecheckJobs struct {
+	mu   sync.Mutex
+	jobs map[primitive.ObjectID]models.RecheckJob
+}
+
+func (m *memoryRecheckJobs) Create(ctx context.Context, job *models.RecheckJob) error {
+	job.ID = primitive.NewObjectID()
+	return m.Save(ctx, job)
+}
+
+func (m *memoryRecheckJobs) Save(ctx context.Context, job *models.RecheckJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[job.ID] = *job
+	return nil
+}
+
+func (m *memoryRecheckJobs) GetByID(ctx context.Context, id primitive.ObjectID) (*models.RecheckJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &job, nil
+}
+
+func (m *memoryRecheckJobs) FindRecent(ctx context.Context, prizeDate string, limit int64) ([]models.RecheckJob, error) {
+	return nil, nil
+}
+
+func TestRecheckAfterDeleteAndRollback(t *testing.T) {
+	ctx := context.Background()
+	const date = "2026-10-16"
+	stat := &models.Statistics{
+		ID:        primitive.NewObjectID(),
+		Date:      date,
+		Prize1:    "123456",
+		First3One: "777",
+		First3Two: "888",
+		Last3One:  "999",
+		Last3Two:  "111",
+		Last2:     "99",
+	}
+	winner := models.Collection{ID: primitive.NewObjectID(), TicketNumber: "123456", TicketQuantity: 1, PrizeDate: date}
+	loser := models.Collection{ID: primitive.NewObjectID(), TicketNumber: "000000", TicketQuantity: 1, PrizeDate: date}
+	otherDraw := models.Collection{ID: primitive.NewObjectID(), TicketNumber: "123456", TicketQuantity: 1, PrizeDate: "2026-11-01"}
+
+	collections := &memoryCollections{items: []models.Collection{winner, loser, otherDraw}}
+	draws := &memoryDraws{draws: map[string]*models.Statistics{}}
+	jobs := &memoryRecheckJobs{jobs: map[primitive.ObjectID]models.RecheckJob{}}
+	r := &PrizeRechecker{
+		collectionRepo: collections,
+		statisticsRepo: draws,
+		jobRepo:        jobs,
+		prizeEngine:    prize.Default(),
+		locks:          make(map[string]*sync.Mutex),
+	}
+
+	recheck := func(trigger string) *models.RecheckJob {
+		t.Helper()
+		job, err := r.Start(ctx, date, trigger)
+		if err != nil {
+			t.Fatalf("Start(%s): %v", trigger, err)
+		}
+		r.Wait()
+		done, _ := jobs.GetByID(ctx, job.ID)
+		if done.Status != models.RecheckStatusCompleted || done.Total != 2 || done.Processed != 2 {
+			t.Fatalf("%s job = %+v, want completed with 2 tickets", trigger, done)
+		}
+		return done
+	}
+
+	draws.put(stat)
+	recheck("create")
+	if got := collections.get(winner.ID); got.PrizeAmount != 6000000 {
+		t.Fatalf("after create: winner = %+v", got)
+	}
+
+	// ลบผลรางวัล: สลากกลับไปเป็นรอผลแต่ยังอยู่ในงวดเดิม
+	draws.delete(date)
+	job := recheck("delete")
+	if job.Outcomes.Pending != 2 {
+		t.Errorf("delete outcomes = %+v, want 2 pending", job.Outcomes)
+	}
+	for _, id := range []primitive.ObjectID{winner.ID, loser.ID} {
+		got := collections.get(id)
+		if got.PrizeType != "" || got.PrizeAmount != 0 || got.PrizeDate != date {
+			t.Errorf("after delete: %+v, want pending on %s", got, date)
+		}
+	}
+
+	// ย้อนผลรางวัลกลับมา: สลากชุดเดิมถูกตรวจรางวัลอีกครั้ง
+	draws.put(stat)
+	job = recheck("rollback")
+	if job.Outcomes.Win != 1 || job.Outcomes.Lose != 1 {
+		t.Errorf("rollback outcomes = %+v, want 1 win and 1 lose", job.Outcomes)
+	}
+	if got := collections.get(winner.ID); got.PrizeAmount != 6000000 || got.PrizeType == "" {
+		t.Errorf("after rollback: winner = %+v", got)
+	}
+	if got := collections.get(loser.ID); got.PrizeType != "lose" {
+		t.Errorf("after rollback: loser = %+v", got)
+	}
+	if got := collections.get(otherDraw.ID); got.PrizeType != "" {
+		t.Errorf("ticket of another draw was checked: %+v", got)
+	}
+}
@@ -0,0 +1,213 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+// subscriberBatchSize คือจำนวนผู้ใช้ที่ส่งต่อหนึ่งรอบ (และบันทึกความคืบหน้าหนึ่งครั้ง)
+const subscriberBatchSize = 100
+
+// errStopping บอกว่างานหยุดกลางคันเพราะเซิร์ฟเวอร์กำลังปิด
+var errStopping = errors.New("stopping")
+
+// MessageBuilder เตรียมข้อมูลของงานหนึ่งงาน (เช่นอ่านผลรางวัลของงวดจาก job.Ref)
+// แล้วคืน UserMessage ที่สร้างข้อความของผู้ใช้แต่ละคน
+type MessageBuilder func(ctx context.Context, job *models.NotifyJob) (UserMessage, error)
+
+// UserMessage คืนฟังก์ชันสร้างข้อความถึง user ตามภาษาที่เขาเลือก
+// หรือ nil ถ้าไม่มีอะไรต้องส่งถึงผู้ใช้คนนี้
+type UserMessage func(ctx context.Context, user *models.User) (func(lang string) notify.Message, error)
+
+// SubscriberNotifier ส่งการแจ้งเตือนเหตุการณ์ที่ผู้ใช้เลือกรับเอง (เช่นงวดใหม่) ถึงทุกคนที่เปิดรับ
+// งานถูกบันทึกใน notify_jobs ก่อนเริ่ม และบันทึกผู้ใช้คนสุดท้ายที่ส่งแล้วทุกรอบ
+// ปิดเซิร์ฟเวอร์กลางคันงานจะทำต่อจากคนถัดไปเมื่อเริ่มใหม่ (Resume)
+// ข้อความที่ซ้ำกับที่ส่งไปแล้วถูกกันด้วย idempotency key ของ outbox
+type SubscriberNotifier struct {
+	jobRepo   notifyJobStore
+	prefsRepo subscriberStore
+	userRepo  subscriberUsers
+	notifier  userNotifier
+	builders  map[string]MessageBuilder
+	batchSize int64
+	stopping  chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// ส่วนของ repository และ UserNotifier ที่ SubscriberNotifier ใช้ แยกไว้ให้ทดสอบได้โดยไม่ต้องมี MongoDB
+type notifyJobStore interface {
+	Create(ctx context.Context, job *models.NotifyJob) (bool, error)
+	Save(ctx context.Context, job *models.NotifyJob) error
+	FindUnfinished(ctx context.Context) ([]models.NotifyJob, error)
+}
+
+type subscriberStore interface {
+	FindUserIDsByEvent(ctx context.Context, event, afterID string, limit int64) ([]string, error)
+}
+
+type subscriberUsers interface {
+	FindByID(id string) (*models.User, error)
+}
+
+type userNotifier interface {
+	Notify(ctx context.Context, user *models.User, event string, build func(lang string) notify.Message) error
+}
+
+func NewSubscriberNotifier(jobRepo *repositories.NotifyJobRepository, prefsRepo *repositories.PreferencesRepository, userRepo *repositories.UserRepository, notifier *UserNotifier) *SubscriberNotifier {
+	return newSubscriberNotifier(jobRepo, prefsRepo, userRepo, notifier)
+}
+
+func newSubscriberNotifier(jobRepo notifyJobStore, prefsRepo subscriberStore, userRepo subscriberUsers, notifier userNotifier) *SubscriberNotifier {
+	return &SubscriberNotifier{
+		jobRepo:   jobRepo,
+		prefsRepo: prefsRepo,
+		userRepo:  userRepo,
+		notifier:  notifier,
+		builders:  make(map[string]MessageBuilder),
+		batchSize: subscriberBatchSize,
+		stopping:  make(chan struct{}),
+	}
+}
+
+// Handle sets how messages about event are built. Call it before Start and Resume
+func (s *SubscriberNotifier) Handle(event string, build MessageBuilder) {
+	s.builders[event] = build
+}
+
+// Start บันทึกงานแจ้ง event เรื่อง ref ถึงผู้ที่สมัครรับ แล้วเริ่มทำในเบื้องหลัง
+// ถ้ามีงานของ event และ ref เดียวกันอยู่แล้วจะไม่สร้างซ้ำ
+func (s *SubscriberNotifier) Start(ctx context.Context, event, ref string) error {
+	if s.builders[event] == nil {
+		return fmt.Errorf("no message builder for %s", event)
+	}
+	job := &models.NotifyJob{
+		Key:    event + ":" + ref,
+		Event:  event,
+		Ref:    ref,
+		Status: models.NotifyJobQueued,
+	}
+	created, err := s.jobRepo.Create(ctx, job)
+	if err != nil || !created {
+		return err
+	}
+	s.run(*job)
+	return nil
+}
+
+// Resume เริ่มงานที่ยังไม่เสร็จจากครั้งก่อนต่อ (เรียกตอนเริ่มเซิร์ฟเวอร์)
+func (s *SubscriberNotifier) Resume(ctx context.Context) error {
+	jobs, err := s.jobRepo.FindUnfinished(ctx)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		log.Printf("Resuming notify job %s (%s) after user %q", job.ID.Hex(), job.Key, job.LastUserID)
+		s.run(job)
+	}
+	return nil
+}
+
+// Stop หยุดงานทั้งหมดหลังรอบที่กำลังส่งอยู่ แล้วรอจนหยุดครบ (ใช้ตอนปิดเซิร์ฟเวอร์)
+// งานที่ยังไม่เสร็จคงสถานะ running ไว้ให้ Resume ทำต่อ
+func (s *SubscriberNotifier) Stop() {
+	s.stopOnce.Do(func() { close(s.stopping) })
+	s.wg.Wait()
+}
+
+func (s *SubscriberNotifier) run(job models.NotifyJob) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.process(&job)
+	}()
+}
+
+func (s *SubscriberNotifier) process(job *models.NotifyJob) {
+	ctx := context.Background()
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
+	job.Status = models.NotifyJobRunning
+	s.save(ctx, job)
+
+	err := s.notifyAll(ctx, job)
+	if errors.Is(err, errStopping) {
+		log.Printf("Notify job %s paused after user %q", job.ID.Hex(), job.LastUserID)
+		return
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		log.Printf("Notify job %s (%s) failed: %v", job.ID.Hex(), job.Key, err)
+		job.Status = models.NotifyJobFailed
+		job.Error = err.Error()
+	} else {
+		log.Printf("Notify job %s (%s) completed: %d users", job.ID.Hex(), job.Key, job.Processed)
+		job.Status = models.NotifyJobCompleted
+	}
+	s.save(ctx, job)
+}
+
+func (s *SubscriberNotifier) notifyAll(ctx context.Context, job *models.NotifyJob) error {
+	prepare := s.builders[job.Event]
+	if prepare == nil {
+		return fmt.Errorf("no message builder for %s", job.Event)
+	}
+	message, err := prepare(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-s.stopping:
+			return errStopping
+		default:
+		}
+
+		userIDs, err := s.prefsRepo.FindUserIDsByEvent(ctx, job.Event, job.LastUserID, s.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+		for _, userID := range userIDs {
+			// ไม่พบผู้ใช้ = บัญชีที่ถูกลบไปแล้ว ข้ามไป
+			if user, err := s.userRepo.FindByID(userID); err == nil {
+				if err := s.notifyUser(ctx, job, message, user); err != nil {
+					log.Printf("Failed to notify %s about %s: %v", user.Email, job.Event, err)
+					job.Failed++
+				}
+			}
+			job.LastUserID = userID
+			job.Processed++
+		}
+		s.save(ctx, job)
+	}
+}
+
+func (s *SubscriberNotifier) notifyUser(ctx context.Context, job *models.NotifyJob, message UserMessage, user *models.User) error {
+	build, err := message(ctx, user)
+	if err != nil || build == nil {
+		return err
+	}
+	return s.notifier.Notify(ctx, user, job.Event, build)
+}
+
+func (s *SubscriberNotifier) save(ctx context.Context, job *models.NotifyJob) {
+	if err := s.jobRepo.Save(ctx, job); err != nil {
+		log.Printf("Failed to save notify job %s: %v", job.ID.Hex(), err)
+	}
+}
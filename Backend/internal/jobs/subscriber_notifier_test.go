@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+)
+
+// memoryNotifyJobs กันงานที่ key ซ้ำเหมือน unique index ของ notify_jobs
+type memoryNotifyJobs struct {
+	mu   sync.Mutex
+	jobs []models.NotifyJob
+}
+
+func (m *memoryNotifyJobs) Create(ctx context.Context, job *models.NotifyJob) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.jobs {
+		if existing.Key == job.Key {
+			return false, nil
+		}
+	}
+	job.ID = primitive.NewObjectID()
+	m.jobs = append(m.jobs, *job)
+	return true, nil
+}
+
+func (m *memoryNotifyJobs) Save(ctx context.Context, job *models.NotifyJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.jobs {
+		if m.jobs[i].ID == job.ID {
+			m.jobs[i] = *job
+		}
+	}
+	return nil
+}
+
+func (m *memoryNotifyJobs) FindUnfinished(ctx context.Context) ([]models.NotifyJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var jobs []models.NotifyJob
+	for _, job := range m.jobs {
+		if job.Status == models.NotifyJobQueued || job.Status == models.NotifyJobRunning {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (m *memoryNotifyJobs) only(t *testing.T) models.NotifyJob {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.jobs) != 1 {
+		t.Fatalf("%d jobs, want 1", len(m.jobs))
+	}
+	return m.jobs[0]
+}
+
+// subscribers is a sorted list of user ids that opted in to every event
+type subscribers []string
+
+func (s subscribers) FindUserIDsByEvent(ctx context.Context, event, afterID string, limit int64) ([]string, error) {
+	var page []string
+	for _, id := range s {
+		if id > afterID && int64(len(page)) < limit {
+			page = append(page, id)
+		}
+	}
+	return page, nil
+}
+
+// accounts finds every user except the deleted ones
+type accounts map[string]bool
+
+func (a accounts) FindByID(id string) (*models.User, error) {
+	if a[id] {
+		return nil, errors.New("not found")
+	}
+	return &models.User{Email: id + "@example.com"}, nil
+}
+
+// sentTo records who was notified; after calls messages it runs hook once
+type sentTo struct {
+	mu    sync.Mutex
+	to    []string
+	after int
+	hook  func()
+}
+
+func (s *sentTo) Notify(ctx context.Context, user *models.User, event string, build func(lang string) notify.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg := build(models.LanguageThai)
+	s.to = append(s.to, user.Email+" "+msg.Text)
+	if len(s.to) == s.after && s.hook != nil {
+		s.hook()
+	}
+	return nil
+}
+
+func (s *sentTo) sorted() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	to := append([]string(nil), s.to...)
+	sort.Strings(to)
+	return to
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func drawBuilder(ctx context.Context, job *models.NotifyJob) (UserMessage, error) {
+	return func(ctx context.Context, user *models.User) (func(lang string) notify.Message, error) {
+		return func(lang string) notify.Message {
+			return notify.Message{Text: "draw " + job.Ref}
+		}, nil
+	}, nil
+}
+
+func TestSubscriberNotifierSendsToEverySubscriberOnce(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryNotifyJobs{}
+	sent := &sentTo{}
+	s := newSubscriberNotifier(store, subscribers{"u1", "u2", "u3", "u4", "u5"}, accounts{"u3": true}, sent)
+	s.batchSize = 2
+	s.Handle(models.EventNewDraw, drawBuilder)
+
+	if err := s.Start(ctx, models.EventNewDraw, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	// งวดเดียวกันถูกสั่งซ้ำ ต้องไม่เกิดงานที่สอง
+	if err := s.Start(ctx, models.EventNewDraw, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	s.wg.Wait()
+
+	want := []string{"u1@example.com draw abc", "u2@example.com draw abc", "u4@example.com draw abc", "u5@example.com draw abc"}
+	if got := sent.sorted(); !equalStrings(got, want) {
+		t.Fatalf("sent to %q, want %q", got, want)
+	}
+	job := store.only(t)
+	if job.Status != models.NotifyJobCompleted || job.Processed != 5 || job.LastUserID != "u5" {
+		t.Errorf("job = %+v, want completed after u5", job)
+	}
+
+	if err := s.Start(ctx, "unknown", "x"); err == nil {
+		t.Error("Start accepted an event without a builder")
+	}
+}
+
+func TestSubscriberNotifierResumesAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryNotifyJobs{}
+	users := subscribers{"u1", "u2", "u3", "u4", "u5"}
+
+	// เซิร์ฟเวอร์แรกปิดระหว่างส่งรอบแรก: รอบนั้นส่งจนจบแล้วงานหยุดค้างไว้
+	first := &sentTo{after: 1}
+	s := newSubscriberNotifier(store, users, accounts{}, first)
+	s.batchSize = 2
+	s.Handle(models.EventNewDraw, drawBuilder)
+	first.hook = func() { s.stopOnce.Do(func() { close(s.stopping) }) }
+	if err := s.Start(ctx, models.EventNewDraw, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	s.wg.Wait()
+
+	job := store.only(t)
+	if job.Status != models.NotifyJobRunning || job.LastUserID != "u2" {
+		t.Fatalf("stopped job = %+v, want running after u2", job)
+	}
+
+	// เซิร์ฟเวอร์ใหม่ทำต่อจากคนถัดไป
+	second := &sentTo{}
+	s = newSubscriberNotifier(store, users, accounts{}, second)
+	s.batchSize = 2
+	s.Handle(models.EventNewDraw, drawBuilder)
+	if err := s.Resume(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.wg.Wait()
+
+	if got := first.sorted(); len(got) != 2 {
+		t.Errorf("first server sent to %q, want u1 and u2", got)
+	}
+	got := second.sorted()
+	want := []string{"u3@example.com draw abc", "u4@example.com draw abc", "u5@example.com draw abc"}
+	if !equalStrings(got, want) {
+		t.Fatalf("resumed job sent to %q, want %q", got, want)
+	}
+	if job := store.only(t); job.Status != models.NotifyJobCompleted || job.Processed != 5 {
+		t.Errorf("resumed job = %+v, want completed with 5 users", job)
+	}
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+// UserNotifier ส่งการแจ้งเตือนถึงผู้ใช้แต่ละคนตามการตั้งค่าของเขา:
+// ข้ามเหตุการณ์ที่ปิดไว้ ใช้ภาษาที่เลือก ส่งทุกช่องทางที่เปิดและติดต่อได้
+// และเลื่อนข้อความที่เกิดในช่วงเวลางดแจ้งเตือนไปส่งตอนช่วงเวลานั้นจบ
+type UserNotifier struct {
+	prefsRepo *repositories.PreferencesRepository
+	userRepo  *repositories.UserRepository
+	outbox    *Outbox
+}
+
+func NewUserNotifier(prefsRepo *repositories.PreferencesRepository, userRepo *repositories.UserRepository, outbox *Outbox) *UserNotifier {
+	return &UserNotifier{
+		prefsRepo: prefsRepo,
+		userRepo:  userRepo,
+		outbox:    outbox,
+	}
+}
+
+// Notify sends the message built by build (in the user's language) about event.
+// build is only called if the user wants the event. If none of the user's
+// channels can reach them (e.g. Telegram is on but no longer linked), email is used
+func (n *UserNotifier) Notify(ctx context.Context, user *models.User, event string, build func(lang string) notify.Message) error {
+	prefs, err := n.prefsRepo.Get(ctx, user.ID.Hex())
+	if err != nil {
+		return err
+	}
+	if !prefs.Wants(event) {
+		return nil
+	}
+
+	msg := build(prefs.Language)
+	if event != models.EventSecurityAlert {
+		msg.NotBefore = prefs.QuietHours.QuietUntil(time.Now().In(draws.Location))
+	}
+
+	var errs []error
+	sent := false
+	for _, channel := range models.Channels {
+		to := user.Recipient(channel)
+		if !prefs.ChannelEnabled(channel) || to == "" || !n.outbox.Has(channel) {
+			continue
+		}
+		m := msg
+		m.To = to
+		errs = append(errs, n.outbox.Channel(channel).Notify(ctx, m))
+		sent = true
+	}
+	if !sent {
+		msg.To = user.Email
+		errs = append(errs, n.outbox.Channel(models.ChannelEmail).Notify(ctx, msg))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,242 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/prize"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+// digestHour คือเวลา (เวลาประเทศไทย) ของวันจันทร์ที่เริ่มส่งสรุปประจำสัปดาห์
+const digestHour = 9
+
+// digestCheckInterval คือความถี่ที่ตรวจว่าถึงเวลาส่งสรุปของสัปดาห์หรือยัง
+const digestCheckInterval = 15 * time.Minute
+
+// WeeklyDigest ส่งสรุปประจำสัปดาห์ถึงผู้ใช้ที่เปิด weeklyDigest ทุกวันจันทร์ตั้งแต่ 9 โมงเช้า:
+// ผลรางวัลของงวดที่ออกในสัปดาห์ก่อน สลากของผู้ใช้ในงวดเหล่านั้น และงวดถัดไป
+// การส่งทำผ่าน SubscriberNotifier หนึ่งงานต่อสัปดาห์ เซิร์ฟเวอร์ที่เริ่มใหม่ในวันจันทร์จึงไม่ส่งซ้ำ
+type WeeklyDigest struct {
+	subscribers    *SubscriberNotifier
+	statisticsRepo digestDraws
+	collectionRepo digestTickets
+	schedule       digestSchedule
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// ส่วนของ repository และปฏิทินงวดที่ WeeklyDigest ใช้
+type digestDraws interface {
+	GetByDateRange(ctx context.Context, from, to string) ([]models.Statistics, error)
+}
+
+type digestTickets interface {
+	Summarize(ctx context.Context, email string, opts repositories.SummaryOptions, now time.Time) (*models.CollectionSummary, error)
+}
+
+type digestSchedule interface {
+	Next(ctx context.Context, at time.Time) (*models.Draw, error)
+}
+
+func NewWeeklyDigest(subscribers *SubscriberNotifier, statisticsRepo *repositories.StatisticsRepository, collectionRepo *repositories.CollectionRepository, schedule *draws.Schedule) *WeeklyDigest {
+	d := &WeeklyDigest{
+		subscribers:    subscribers,
+		statisticsRepo: statisticsRepo,
+		collectionRepo: collectionRepo,
+		schedule:       schedule,
+		stop:           make(chan struct{}),
+	}
+	subscribers.Handle(models.EventWeeklyDigest, d.build)
+	return d
+}
+
+// Start ตรวจทุก digestCheckInterval ว่าถึงเวลาส่งสรุปของสัปดาห์นี้หรือยัง
+func (d *WeeklyDigest) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+		for {
+			d.check(time.Now())
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop หยุดตรวจเวลา (ใช้ตอนปิดเซิร์ฟเวอร์)
+func (d *WeeklyDigest) Stop() {
+	d.stopOnce.Do(func() { close(d.stop) })
+	d.wg.Wait()
+}
+
+func (d *WeeklyDigest) check(now time.Time) {
+	week, ok := digestWeek(now)
+	if !ok {
+		return
+	}
+	if err := d.subscribers.Start(context.Background(), models.EventWeeklyDigest, week); err != nil {
+		log.Printf("Failed to start weekly digest for %s: %v", week, err)
+	}
+}
+
+// digestWeek returns the Monday (YYYY-MM-DD) whose digest is due at now. The
+// digest is sent from digestHour until the end of Monday, Thai time; a server
+// that is down all Monday skips that week rather than sending it late
+func digestWeek(now time.Time) (string, bool) {
+	t := now.In(draws.Location)
+	if t.Weekday() != time.Monday || t.Hour() < digestHour {
+		return "", false
+	}
+	return t.Format("2006-01-02"), true
+}
+
+// digestPeriod returns the Monday and Sunday of the week before the Monday week
+func digestPeriod(week string) (from, to string, err error) {
+	monday, err := time.ParseInLocation("2006-01-02", week, draws.Location)
+	if err != nil {
+		return "", "", err
+	}
+	return monday.AddDate(0, 0, -7).Format("2006-01-02"), monday.AddDate(0, 0, -1).Format("2006-01-02"), nil
+}
+
+// build อ่านผลรางวัลของสัปดาห์และงวดถัดไปครั้งเดียวต่องาน แล้วสรุปสลากของผู้ใช้ทีละคน
+func (d *WeeklyDigest) build(ctx context.Context, job *models.NotifyJob) (UserMessage, error) {
+	from, to, err := digestPeriod(job.Ref)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := d.statisticsRepo.GetByDateRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	monday, _ := time.ParseInLocation("2006-01-02", job.Ref, draws.Location)
+	next, err := d.schedule.Next(ctx, monday)
+	if err != nil {
+		// ไม่มีงวดถัดไปในปฏิทิน ยังส่งสรุปได้
+		next = nil
+	}
+
+	digest := weeklyDigest{week: job.Ref, from: from, to: to, next: next}
+	// GetByDateRange เรียงจากใหม่ไปเก่า สรุปเรียงตามวันที่
+	for i := len(stats) - 1; i >= 0; i-- {
+		digest.results = append(digest.results, stats[i])
+	}
+
+	return func(ctx context.Context, user *models.User) (func(lang string) notify.Message, error) {
+		summary, err := d.collectionRepo.Summarize(ctx, user.Email, repositories.SummaryOptions{GroupBy: repositories.SummaryGroupByDraw}, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		tickets := make(map[string]models.SummaryGroup, len(summary.Groups))
+		for _, group := range summary.Groups {
+			tickets[group.Key] = group
+		}
+		if !digest.worthSending(tickets) {
+			return nil, nil
+		}
+		return func(lang string) notify.Message {
+			return digest.message(lang, tickets)
+		}, nil
+	}, nil
+}
+
+// weeklyDigest คือข้อมูลของสรุปหนึ่งสัปดาห์ที่เหมือนกันสำหรับทุกคน
+type weeklyDigest struct {
+	week     string              // วันจันทร์ที่ส่งสรุป
+	from, to string              // ช่วงวันที่ที่สรุป
+	results  []models.Statistics // ผลรางวัลที่ออกในช่วงนั้น เรียงตามวันที่
+	next     *models.Draw        // งวดถัดไป (nil ถ้าไม่มีในปฏิทิน)
+}
+
+// worthSending: ไม่ส่งสรุปที่ว่างเปล่า (ไม่มีงวดที่ออกรางวัลและไม่มีสลากรอผลงวดถัดไป)
+func (w *weeklyDigest) worthSending(tickets map[string]models.SummaryGroup) bool {
+	if len(w.results) > 0 {
+		return true
+	}
+	return w.next != nil && tickets[w.next.Date].TotalTickets > 0
+}
+
+// message สร้างสรุปของผู้ใช้หนึ่งคน tickets คือยอดสลากของผู้ใช้แยกตามงวด (prize_date)
+func (w *weeklyDigest) message(lang string, tickets map[string]models.SummaryGroup) notify.Message {
+	english := lang == models.LanguageEnglish
+	date := draws.ThaiDate
+	if english {
+		date = draws.EnglishDate
+	}
+
+	var b strings.Builder
+	if english {
+		fmt.Fprintf(&b, "📊 Your week, %s - %s\n", date(w.from), date(w.to))
+	} else {
+		fmt.Fprintf(&b, "📊 สรุปประจำสัปดาห์ %s - %s\n", date(w.from), date(w.to))
+	}
+
+	if len(w.results) == 0 {
+		if english {
+			b.WriteString("\nNo draw this week.\n")
+		} else {
+			b.WriteString("\nไม่มีการออกรางวัลในสัปดาห์นี้\n")
+		}
+	}
+	for _, stat := range w.results {
+		group := tickets[stat.Date]
+		if english {
+			fmt.Fprintf(&b, "\n📅 Draw of %s : 1st prize %s\n", date(stat.Date), stat.Prize1)
+		} else {
+			fmt.Fprintf(&b, "\n📅 งวดวันที่ %s : รางวัลที่ 1 %s\n", date(stat.Date), stat.Prize1)
+		}
+		switch {
+		case group.TotalTickets == 0 && english:
+			b.WriteString("🎫 You had no tickets in this draw\n")
+		case group.TotalTickets == 0:
+			b.WriteString("🎫 คุณไม่มีสลากในงวดนี้\n")
+		case english:
+			fmt.Fprintf(&b, "🎫 Your tickets: %d, winning entries: %d, prizes: %s THB\n",
+				group.TotalTickets, group.TotalWins, prize.FormatBaht(group.TotalPrize))
+		default:
+			fmt.Fprintf(&b, "🎫 สลากของคุณ %d ใบ ถูกรางวัล %d รายการ รวม %s บาท\n",
+				group.TotalTickets, group.TotalWins, prize.FormatBaht(group.TotalPrize))
+		}
+	}
+
+	if w.next != nil {
+		pending := tickets[w.next.Date].TotalTickets
+		if english {
+			fmt.Fprintf(&b, "\n⏭️ Next draw: %s", date(w.next.Date))
+			if pending > 0 {
+				fmt.Fprintf(&b, " (%d of your tickets are waiting)", pending)
+			}
+		} else {
+			fmt.Fprintf(&b, "\n⏭️ งวดถัดไป: %s", date(w.next.Date))
+			if pending > 0 {
+				fmt.Fprintf(&b, " (สลากของคุณรอผล %d ใบ)", pending)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	subject := "Lotterich - สรุปประจำสัปดาห์"
+	if english {
+		subject = "Lotterich - Your weekly digest"
+	}
+	return notify.Message{
+		Subject: subject,
+		Text:    strings.TrimRight(b.String(), "\n"),
+		Key:     "weekly-digest:" + w.week,
+	}
+}
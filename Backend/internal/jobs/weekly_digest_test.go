@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+type digestStats []models.Statistics
+
+// GetByDateRange คืนผลรางวัลจากใหม่ไปเก่าเหมือน StatisticsRepository
+func (d digestStats) GetByDateRange(ctx context.Context, from, to string) ([]models.Statistics, error) {
+	var found []models.Statistics
+	for i := len(d) - 1; i >= 0; i-- {
+		if d[i].Date >= from && d[i].Date <= to {
+			found = append(found, d[i])
+		}
+	}
+	return found, nil
+}
+
+// ticketGroups คือยอดสลากแยกตามงวดของผู้ใช้แต่ละคน (ตามอีเมล)
+type ticketGroups map[string][]models.SummaryGroup
+
+func (t ticketGroups) Summarize(ctx context.Context, email string, opts repositories.SummaryOptions, now time.Time) (*models.CollectionSummary, error) {
+	return &models.CollectionSummary{GroupBy: opts.GroupBy, Groups: t[email]}, nil
+}
+
+type nextDraw string
+
+func (n nextDraw) Next(ctx context.Context, at time.Time) (*models.Draw, error) {
+	return &models.Draw{Date: string(n), RegularDate: string(n)}, nil
+}
+
+func TestDigestWeek(t *testing.T) {
+	tests := []struct {
+		at   time.Time
+		week string
+	}{
+		{time.Date(2026, 10, 19, 8, 59, 0, 0, draws.Location), ""},
+		{time.Date(2026, 10, 19, 9, 0, 0, 0, draws.Location), "2026-10-19"},
+		{time.Date(2026, 10, 19, 23, 59, 0, 0, draws.Location), "2026-10-19"},
+		{time.Date(2026, 10, 20, 9, 0, 0, 0, draws.Location), ""},
+		{time.Date(2026, 10, 18, 12, 0, 0, 0, draws.Location), ""},
+		// 02:00 UTC วันจันทร์ คือ 09:00 เวลาประเทศไทย
+		{time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC), "2026-10-19"},
+		// 20:00 UTC วันอาทิตย์ คือ 03:00 วันจันทร์ เวลาประเทศไทย ยังไม่ถึงเวลา
+		{time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC), ""},
+	}
+	for _, tt := range tests {
+		week, ok := digestWeek(tt.at)
+		if week != tt.week || ok != (tt.week != "") {
+			t.Errorf("digestWeek(%v) = %q, %v; want %q", tt.at, week, ok, tt.week)
+		}
+	}
+}
+
+func TestWeeklyDigest(t *testing.T) {
+	store := &memoryNotifyJobs{}
+	sent := &sentTo{}
+	s := newSubscriberNotifier(store, subscribers{"u1", "u2"}, accounts{}, sent)
+	d := &WeeklyDigest{
+		subscribers: s,
+		statisticsRepo: digestStats{
+			{Date: "2026-10-01", Prize1: "111111"},
+			{Date: "2026-10-16", Prize1: "123456"},
+		},
+		collectionRepo: ticketGroups{
+			"u1@example.com": {
+				{Key: "2026-10-16", TotalTickets: 3, TotalWins: 1, TotalPrize: 2000},
+				{Key: "2026-11-01", TotalTickets: 2},
+			},
+		},
+		schedule: nextDraw("2026-11-01"),
+	}
+	s.Handle(models.EventWeeklyDigest, d.build)
+
+	// ตรวจซ้ำหลายรอบในวันจันทร์เดียวกัน ส่งครั้งเดียว
+	monday := time.Date(2026, 10, 19, 9, 15, 0, 0, draws.Location)
+	d.check(monday)
+	d.check(monday.Add(digestCheckInterval))
+	s.wg.Wait()
+
+	got := sent.sorted()
+	if len(got) != 2 {
+		t.Fatalf("sent %d digests, want 2: %q", len(got), got)
+	}
+	u1, u2 := got[0], got[1]
+	for _, want := range []string{
+		"สรุปประจำสัปดาห์ 12 ตุลาคม 2569 - 18 ตุลาคม 2569",
+		"งวดวันที่ 16 ตุลาคม 2569 : รางวัลที่ 1 123456",
+		"สลากของคุณ 3 ใบ ถูกรางวัล 1 รายการ รวม 2,000 บาท",
+		"งวดถัดไป: 1 พฤศจิกายน 2569 (สลากของคุณรอผล 2 ใบ)",
+	} {
+		if !strings.Contains(u1, want) {
+			t.Errorf("u1 digest %q does not contain %q", u1, want)
+		}
+	}
+	if strings.Contains(u1, "111111") {
+		t.Errorf("u1 digest has a draw from another week: %q", u1)
+	}
+	if !strings.HasPrefix(u2, "u2@example.com") || !strings.Contains(u2, "คุณไม่มีสลากในงวดนี้") || strings.Contains(u2, "รอผล") {
+		t.Errorf("u2 digest = %q", u2)
+	}
+
+	// สัปดาห์ที่ไม่มีงวดออกรางวัล ส่งเฉพาะคนที่มีสลากรอผล
+	sent.to = nil
+	d.check(monday.AddDate(0, 0, 7))
+	s.wg.Wait()
+	got = sent.sorted()
+	if len(got) != 1 || !strings.HasPrefix(got[0], "u1@example.com") || !strings.Contains(got[0], "ไม่มีการออกรางวัลในสัปดาห์นี้") {
+		t.Errorf("digests of a week without draws = %q", got)
+	}
+}
+
+func TestWeeklyDigestEnglish(t *testing.T) {
+	digest := weeklyDigest{
+		week:    "2026-10-19",
+		from:    "2026-10-12",
+		to:      "2026-10-18",
+		results: []models.Statistics{{Date: "2026-10-16", Prize1: "123456"}},
+		next:    &models.Draw{Date: "2026-11-01"},
+	}
+	msg := digest.message(models.LanguageEnglish, map[string]models.SummaryGroup{
+		"2026-10-16": {TotalTickets: 1, TotalWins: 1, TotalPrize: 6000000},
+	})
+	want := "📊 Your week, 12 October 2026 - 18 October 2026\n\n" +
+		"📅 Draw of 16 October 2026 : 1st prize 123456\n" +
+		"🎫 Your tickets: 1, winning entries: 1, prizes: 6,000,000 THB\n\n" +
+		"⏭️ Next draw: 1 November 2026"
+	if msg.Text != want {
+		t.Errorf("Text = %q, want %q", msg.Text, want)
+	}
+	if msg.Subject != "Lotterich - Your weekly digest" || msg.Key != "weekly-digest:2026-10-19" {
+		t.Errorf("message = %+v", msg)
+	}
+}
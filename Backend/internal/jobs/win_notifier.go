@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/user/Lotterich/internal/draws"
+	"github.com/user/Lotterich/internal/models"
+	"github.com/user/Lotterich/internal/notify"
+	"github.com/user/Lotterich/internal/prize"
+	"github.com/user/Lotterich/internal/repositories"
+)
+
+// WinNotifier แจ้งเจ้าของสลากที่ถูกรางวัลหลังตรวจรางวัลของงวดเสร็จ
+// ตามการตั้งค่าการแจ้งเตือนของผู้ใช้ (ดู UserNotifier)
+type WinNotifier struct {
+	userRepo       *repositories.UserRepository
+	collectionRepo *repositories.CollectionRepository
+	notifier       *UserNotifier
+}
+
+func NewWinNotifier(userRepo *repositories.UserRepository, collectionRepo *repositories.CollectionRepository, notifier *UserNotifier) *WinNotifier {
+	return &WinNotifier{
+		userRepo:       userRepo,
+		collectionRepo: collectionRepo,
+		notifier:       notifier,
+	}
+}
+
+// NotifyDraw ส่งข้อความหนึ่งข้อความต่อผู้ใช้ที่มีสลากถูกรางวัลในงวด prizeDate
+// idempotency key มาจากเนื้อหาของรางวัล การตรวจซ้ำที่ได้ผลเดิมจึงไม่แจ้งซ้ำ
+// แต่ถ้าผลรางวัลถูกแก้จนรางวัลที่ได้เปลี่ยน ผู้ใช้จะได้ข้อความใหม่
+func (n *WinNotifier) NotifyDraw(ctx context.Context, prizeDate string) error {
+	winners, err := n.collectionRepo.FindWinnersByPrizeDate(ctx, prizeDate)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(winners); {
+		end := start
+		for end < len(winners) && winners[end].Email == winners[start].Email {
+			end++
+		}
+		if err := n.notifyOwner(ctx, prizeDate, winners[start:end]); err != nil {
+			log.Printf("Failed to notify %s about winning tickets: %v", winners[start].Email, err)
+		}
+		start = end
+	}
+	return nil
+}
+
+func (n *WinNotifier) notifyOwner(ctx context.Context, prizeDate string, tickets []models.Collection) error {
+	user, err := n.userRepo.FindByEmail(tickets[0].Email)
+	if err != nil {
+		// สลากของบัญชีที่ถูกลบไปแล้ว
+		return nil
+	}
+	return n.notifier.Notify(ctx, user, models.EventTicketWon, func(lang string) notify.Message {
+		return winMessage(lang, prizeDate, tickets)
+	})
+}
+
+// winMessage สร้างข้อความแจ้งรางวัล: เลขสลาก ประเภทรางวัล และเงินรางวัลรวม
+func winMessage(lang, prizeDate string, tickets []models.Collection) notify.Message {
+	labelNames := prize.Labels
+	if lang == models.LanguageEnglish {
+		labelNames = prize.EnglishLabels
+	}
+
+	var lines []string
+	total := 0
+	for _, t := range tickets {
+		labels := make([]string, len(t.PrizeTypes))
+		for i, prizeType := range t.PrizeTypes {
+			labels[i] = labelNames[prizeType]
+			if labels[i] == "" {
+				labels[i] = prizeType
+			}
+		}
+		amount := t.PrizeAmount * t.TicketQuantity
+		total += amount
+		if lang == models.LanguageEnglish {
+			lines = append(lines, fmt.Sprintf("🎫 %s (x%d) : %s = %s THB",
+				t.TicketNumber, t.TicketQuantity, strings.Join(labels, ", "), prize.FormatBaht(amount)))
+		} else {
+			lines = append(lines, fmt.Sprintf("🎫 %s (%d ใบ) : %s = %s บาท",
+				t.TicketNumber, t.TicketQuantity, strings.Join(labels, ", "), prize.FormatBaht(amount)))
+		}
+	}
+
+	subject := "Lotterich - ยินดีด้วย! สลากของคุณถูกรางวัล"
+	text := fmt.Sprintf("🎉 ยินดีด้วย! สลากของคุณถูกรางวัล\n\n📅 งวดวันที่ %s\n%s\n\n💰 รวม %s บาท",
+		draws.ThaiDate(prizeDate), strings.Join(lines, "\n"), prize.FormatBaht(total))
+	if lang == models.LanguageEnglish {
+		subject = "Lotterich - Congratulations! Your ticket won"
+		text = fmt.Sprintf("🎉 Congratulations! Your ticket won\n\n📅 Draw of %s\n%s\n\n💰 Total %s THB",
+			draws.EnglishDate(prizeDate), strings.Join(lines, "\n"), prize.FormatBaht(total))
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	return notify.Message{
+		Subject: subject,
+		Text:    text,
+		Key:     "win:" + prizeDate + ":" + tickets[0].Email + ":" + hex.EncodeToString(sum[:8]),
+	}
+}
@@ -0,0 +1,88 @@
+// Package line reads the events that the LINE Messaging API posts to the
+// webhook, and builds links that open a chat with the bot.
+package line
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// SignatureHeader carries the base64 HMAC-SHA256 of the request body, keyed
+// with the channel secret, so the webhook can reject anything LINE didn't send
+const SignatureHeader = "X-Line-Signature"
+
+// VerifySignature reports whether signature was made from body with channelSecret
+func VerifySignature(channelSecret string, body []byte, signature string) bool {
+	if channelSecret == "" || signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+	want, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+// Payload is one webhook request; it may carry several events
+type Payload struct {
+	Destination string  `json:"destination"`
+	Events      []Event `json:"events"`
+}
+
+// Event is one webhook event. Only text messages and unfollow (the user
+// blocked the bot) are used; other kinds are ignored
+type Event struct {
+	Type           string   `json:"type"` // message, follow, unfollow, ...
+	WebhookEventID string   `json:"webhookEventId"`
+	Timestamp      int64    `json:"timestamp"`
+	Source         Source   `json:"source"`
+	Message        *Message `json:"message"`
+}
+
+// Source is where an event came from
+type Source struct {
+	Type    string `json:"type"` // user, group or room
+	UserID  string `json:"userId"`
+	GroupID string `json:"groupId"`
+	RoomID  string `json:"roomId"`
+}
+
+// Message is a message sent to the bot
+type Message struct {
+	ID   string `json:"id"`
+	Type string `json:"type"` // text, image, sticker, ...
+	Text string `json:"text"`
+}
+
+// Text returns the text of a text message, or "" for any other event
+func (e *Event) Text() string {
+	if e.Type != "message" || e.Message == nil || e.Message.Type != "text" {
+		return ""
+	}
+	return strings.TrimSpace(e.Message.Text)
+}
+
+// Private reports whether the event came from a one-to-one chat with the bot
+func (s Source) Private() bool {
+	return s.Type == "user" && s.UserID != ""
+}
+
+// ParseLink returns the code of a "link <code>" message
+func ParseLink(text string) (code string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "link") {
+		return "", false
+	}
+	return fields[1], true
+}
+
+// MessageLink returns the link that opens a chat with the bot (botID is its
+// LINE ID such as "@123abcde") with text already typed in
+func MessageLink(botID, text string) string {
+	return "https://line.me/R/oaMessage/" + url.PathEscape(botID) + "/?" + url.PathEscape(text)
+}
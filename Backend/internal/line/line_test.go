@@ -0,0 +1,113 @@
+package line
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "link_message.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const secret = "8c570fd8c1a2b3c4d5e6f7a8b9c0d1e2"
+	signature := sign(secret, body)
+
+	if !VerifySignature(secret, body, signature) {
+		t.Error("valid signature rejected")
+	}
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-2] = ' '
+	if VerifySignature(secret, tampered, signature) {
+		t.Error("signature accepted for a changed body")
+	}
+	if VerifySignature("other-secret", body, signature) {
+		t.Error("signature accepted with another secret")
+	}
+	if VerifySignature("", body, sign("", body)) {
+		t.Error("signature accepted without a channel secret")
+	}
+	if VerifySignature(secret, body, "not base64!") {
+		t.Error("malformed signature accepted")
+	}
+}
+
+func TestPayload(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "link_message.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Events) != 4 {
+		t.Fatalf("%d events, want 4", len(payload.Events))
+	}
+
+	tests := []struct {
+		text    string
+		private bool
+		code    string
+	}{
+		{"link Yx3kQ9b_Lw2sVd-07HfPq1RtZm8cNeAu", true, "Yx3kQ9b_Lw2sVd-07HfPq1RtZm8cNeAu"},
+		{"", true, ""},             // sticker
+		{"link abc", false, "abc"}, // group
+		{"", true, ""},             // unfollow
+	}
+	for i, tt := range tests {
+		event := payload.Events[i]
+		if got := event.Text(); got != tt.text {
+			t.Errorf("event %d: Text() = %q, want %q", i, got, tt.text)
+		}
+		if got := event.Source.Private(); got != tt.private {
+			t.Errorf("event %d: Private() = %v, want %v", i, got, tt.private)
+		}
+		code, ok := ParseLink(event.Text())
+		if code != tt.code || ok != (tt.code != "") {
+			t.Errorf("event %d: ParseLink = %q, %v; want %q", i, code, ok, tt.code)
+		}
+	}
+	if payload.Events[0].Source.UserID != "U4af4980629a1b2c3d4e5f6a7b8c9d0e1" || payload.Events[3].Type != "unfollow" {
+		t.Errorf("events parsed as %+v", payload.Events)
+	}
+}
+
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		text string
+		code string
+		ok   bool
+	}{
+		{"LINK abc", "abc", true},
+		{"  link   abc  ", "abc", true},
+		{"link", "", false},
+		{"link abc def", "", false},
+		{"hello", "", false},
+	}
+	for _, tt := range tests {
+		code, ok := ParseLink(tt.text)
+		if code != tt.code || ok != tt.ok {
+			t.Errorf("ParseLink(%q) = %q, %v; want %q, %v", tt.text, code, ok, tt.code, tt.ok)
+		}
+	}
+}
+
+func TestMessageLink(t *testing.T) {
+	got := MessageLink("@123abcde", "link Yx3k-Q9b_L")
+	want := "https://line.me/R/oaMessage/@123abcde/?link%20Yx3k-Q9b_L"
+	if got != want {
+		t.Errorf("MessageLink = %q, want %q", got, want)
+	}
+}
@@ -1,16 +1,22 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/user/Lotterich/internal/permissions"
+	"github.com/user/Lotterich/internal/repositories"
 	"github.com/user/Lotterich/internal/utils"
 )
 
 // AuthMiddleware validates JWT tokens and sets user information in the context
-func AuthMiddleware() gin.HandlerFunc {
+// The session in the token is checked on every request, so a revoked session
+// is rejected immediately even if its access token has not expired yet
+func AuthMiddleware(sessionRepo *repositories.SessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -35,12 +41,56 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		session, err := sessionRepo.GetByID(c.Request.Context(), claims.SessionID)
+		if err != nil || session.UserID != claims.UserID || !session.Active(time.Now()) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been logged out"})
+			c.Abort()
+			return
+		}
+		if err := sessionRepo.Touch(c.Request.Context(), session, c.ClientIP()); err != nil {
+			fmt.Printf("Failed to update session last seen: %v\n", err)
+		}
+
 		// Set user ID in context
 		c.Set("userID", claims.UserID)
 		c.Set("userEmail", claims.Email)
 		c.Set("userName", claims.Name)
 		c.Set("userRole", claims.Role)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("emailVerified", claims.EmailVerified)
+		c.Set("permissions", claims.Permissions)
 
 		c.Next()
 	}
 }
+
+// RequireVerifiedEmail blocks accounts that have not confirmed their email yet
+// It must run after AuthMiddleware. A token issued before verification is
+// updated by calling /api/auth/refresh
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("emailVerified") {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "กรุณายืนยันอีเมลก่อนใช้งานส่วนนี้",
+				"code":  "email_not_verified",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission allows the request only when the token grants every listed permission
+// It must run after AuthMiddleware
+func RequirePermission(required ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := c.GetStringSlice("permissions")
+		if !permissions.HasAll(granted, required...) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "คุณไม่มีสิทธิ์ใช้งานส่วนนี้"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
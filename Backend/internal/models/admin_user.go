@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// AdminUserView is a user as shown in the admin user management API
+type AdminUserView struct {
+	ID                    string     `json:"id"`
+	Name                  string     `json:"name"`
+	Email                 string     `json:"email"`
+	Role                  string     `json:"role"`
+	EmailVerified         bool       `json:"emailVerified"`
+	TwoFactorEnabled      bool       `json:"twoFactorEnabled"`
+	Suspended             bool       `json:"suspended"`
+	SuspendedAt           *time.Time `json:"suspendedAt,omitempty"`
+	SuspendReason         string     `json:"suspendReason,omitempty"`
+	PasswordResetRequired bool       `json:"passwordResetRequired"`
+	TicketCount           int64      `json:"ticketCount"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+}
+
+// ToAdminView converts a User to AdminUserView
+func (u *User) ToAdminView(ticketCount int64) AdminUserView {
+	return AdminUserView{
+		ID:                    u.ID.Hex(),
+		Name:                  u.Name,
+		Email:                 u.Email,
+		Role:                  u.Role,
+		EmailVerified:         u.EmailVerified,
+		TwoFactorEnabled:      u.TwoFactorEnabled,
+		Suspended:             u.Suspended,
+		SuspendedAt:           u.SuspendedAt,
+		SuspendReason:         u.SuspendReason,
+		PasswordResetRequired: u.PasswordResetRequired,
+		TicketCount:           ticketCount,
+		CreatedAt:             u.CreatedAt,
+		UpdatedAt:             u.UpdatedAt,
+	}
+}
+
+// UserSearch describes GET /api/admin/users
+type UserSearch struct {
+	// Search matches name or email (case-insensitive)
+	Search    string
+	Role      string
+	Suspended *bool
+	Page      int
+	Limit     int
+}
+
+// ChangeRoleRequest ใช้กับ PATCH /api/admin/users/:id/role
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// SuspendUserRequest ใช้กับ POST /api/admin/users/:id/suspend
+type SuspendUserRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry คือบันทึกการกระทำหนึ่งครั้ง (append-only ไม่มีการแก้ไขหรือลบ)
+type AuditEntry struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ActorID    string                 `bson:"actor_id" json:"actorId"`
+	ActorEmail string                 `bson:"actor_email" json:"actorEmail"`
+	Action     string                 `bson:"action" json:"action"`
+	TargetType string                 `bson:"target_type" json:"targetType"`
+	TargetID   string                 `bson:"target_id" json:"targetId"`
+	Details    map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	// Changes คือค่าที่เปลี่ยนไปของเอกสารเป้าหมาย แยกตามชื่อฟิลด์
+	Changes   map[string]AuditChange `bson:"changes,omitempty" json:"changes,omitempty"`
+	IP        string                 `bson:"ip" json:"ip"`
+	CreatedAt time.Time              `bson:"created_at" json:"createdAt"`
+}
+
+// AuditChange is the value of one field before and after an action
+// From is empty for a created document and To for a deleted one
+type AuditChange struct {
+	From interface{} `bson:"from,omitempty" json:"from,omitempty"`
+	To   interface{} `bson:"to,omitempty" json:"to,omitempty"`
+}
+
+// Audit actions for user management
+const (
+	AuditUserRoleChanged         = "user.role_changed"
+	AuditUserSuspended           = "user.suspended"
+	AuditUserUnsuspended         = "user.unsuspended"
+	AuditUserPasswordResetForced = "user.password_reset_forced"
+)
+
+// Audit actions for draw results and the draw schedule
+const (
+	AuditStatisticsCreated    = "statistics.created"
+	AuditStatisticsUpdated    = "statistics.updated"
+	AuditStatisticsDeleted    = "statistics.deleted"
+	AuditStatisticsRolledBack = "statistics.rolled_back"
+	AuditDrawExceptionCreated = "draw_exception.created"
+	AuditDrawExceptionDeleted = "draw_exception.deleted"
+)
+
+// AuditOutboxReplayed is an admin sending a dead notification again
+const AuditOutboxReplayed = "outbox.replayed"
+
+// Audit actions for auth events; the actor is the account itself
+const (
+	AuditAuthLogin             = "auth.login"
+	AuditAuthLoginFailed       = "auth.login_failed"
+	AuditAuthPasswordChanged   = "auth.password_changed"
+	AuditAuthPasswordReset     = "auth.password_reset"
+	AuditAuthEmailChanged      = "auth.email_changed"
+	AuditAuthTwoFactorEnabled  = "auth.two_factor_enabled"
+	AuditAuthTwoFactorDisabled = "auth.two_factor_disabled"
+	AuditAuthAccountDeleted    = "auth.account_deleted"
+)
+
+// Target types
+const (
+	AuditTargetUser          = "user"
+	AuditTargetStatistics    = "statistics"
+	AuditTargetDrawException = "draw_exception"
+	AuditTargetOutbox        = "outbox"
+)
+
+// AuditSearch describes GET /api/admin/audit-log
+type AuditSearch struct {
+	ActorID    string
+	ActorEmail string
+	// Action matches exactly, or every action in a group when it ends with ".*" (e.g. "auth.*")
+	Action     string
+	TargetType string
+	TargetID   string
+	// From and To limit CreatedAt to [From, To)
+	From  time.Time
+	To    time.Time
+	Page  int
+	Limit int
+}
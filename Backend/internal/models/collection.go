@@ -14,7 +14,42 @@ type Collection struct {
 	Date           time.Time          `bson:"date" json:"date"`
 	PrizeResult    string             `bson:"prize_result" json:"prizeResult"`
 	PrizeType      string             `bson:"prize_type" json:"prizeType"`
+	PrizeTypes     []string           `bson:"prize_types" json:"prizeTypes"`
 	PrizeAmount    int                `bson:"prize_amount" json:"prizeAmount"`
 	PrizeDate      string             `bson:"prize_date" json:"prize_date"`
 	Email          string             `bson:"email" json:"email"`
 }
+
+// CollectionSummary สรุปผลการซื้อสลากของผู้ใช้ (GET /api/collection/summary)
+type CollectionSummary struct {
+	TotalTickets      int               `json:"totalTickets"`
+	TotalSpent        int               `json:"totalSpent"`
+	TotalWins         int               `json:"totalWins"`
+	TotalPrize        int               `json:"totalPrize"`
+	NetProfit         int               `json:"netProfit"`
+	WinCount          int               `json:"winCount"`
+	LoseCount         int               `json:"loseCount"`
+	PendingCount      int               `json:"pendingCount"`
+	WinPercent        float64           `json:"winPercent"`
+	LosePercent       float64           `json:"losePercent"`
+	LastWinningNumber string            `json:"lastWinningNumber"`
+	GroupBy           string            `json:"groupBy,omitempty"`
+	Groups            []SummaryGroup    `json:"groups,omitempty"`
+	MonthlySpending   []MonthlySpending `json:"monthlySpending"`
+}
+
+// SummaryGroup คือยอดรวมของแต่ละกลุ่ม (งวด เดือน หรือปี)
+type SummaryGroup struct {
+	Key          string `bson:"_id" json:"key"`
+	TotalTickets int    `bson:"total_tickets" json:"totalTickets"`
+	TotalSpent   int    `bson:"total_spent" json:"totalSpent"`
+	TotalWins    int    `bson:"total_wins" json:"totalWins"`
+	TotalPrize   int    `bson:"total_prize" json:"totalPrize"`
+	NetProfit    int    `bson:"-" json:"netProfit"`
+}
+
+// MonthlySpending คือยอดซื้อสลากในแต่ละเดือน (YYYY-MM)
+type MonthlySpending struct {
+	Month string `bson:"_id" json:"month"`
+	Spent int    `bson:"spent" json:"spent"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DrawException คือวันออกรางวัลปกติ (วันที่ 1 และ 16) ที่ถูกเลื่อนหรืองดออกรางวัล
+// เช่น งวดวันที่ 1 พฤษภาคม (วันแรงงาน) ที่เลื่อนไปออกวันที่ 2 พฤษภาคม
+type DrawException struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Date      string             `bson:"date" json:"date"`        // วันออกรางวัลปกติ (YYYY-MM-DD)
+	MovedTo   string             `bson:"moved_to" json:"movedTo"` // วันที่ออกรางวัลจริง ว่าง = งดออกรางวัล
+	Note      string             `bson:"note" json:"note"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// Draw คือวันออกรางวัลหนึ่งงวดในปฏิทิน
+type Draw struct {
+	Date        string `json:"date"`        // วันที่ออกรางวัลจริง (YYYY-MM-DD)
+	RegularDate string `json:"regularDate"` // วันออกรางวัลปกติของงวดนี้
+	Moved       bool   `json:"moved"`
+	Note        string `json:"note,omitempty"`
+}
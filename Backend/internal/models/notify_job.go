@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// สถานะของงานส่งการแจ้งเตือนถึงผู้ที่สมัครรับ
+const (
+	NotifyJobQueued    = "queued"
+	NotifyJobRunning   = "running"
+	NotifyJobCompleted = "completed"
+	NotifyJobFailed    = "failed"
+)
+
+// NotifyJob คืองานเบื้องหลังที่ส่งการแจ้งเตือนเหตุการณ์ Event (เช่นงวดใหม่)
+// ถึงผู้ใช้ทุกคนที่เปิดรับ ทีละกลุ่มตามลำดับ user_id และบันทึกความคืบหน้าไว้
+// งานที่ค้างอยู่ตอนปิดเซิร์ฟเวอร์จะทำต่อจาก LastUserID เมื่อเซิร์ฟเวอร์เริ่มใหม่
+type NotifyJob struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Key        string             `bson:"key" json:"key"` // หนึ่งงานต่อ key เช่น new_draw:<statistics id>
+	Event      string             `bson:"event" json:"event"`
+	Ref        string             `bson:"ref" json:"ref"` // สิ่งที่แจ้ง เช่น id ของผลรางวัลงวดใหม่
+	Status     string             `bson:"status" json:"status"`
+	LastUserID string             `bson:"last_user_id,omitempty" json:"lastUserId,omitempty"`
+	Processed  int64              `bson:"processed" json:"processed"`
+	Failed     int64              `bson:"failed" json:"failed"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	StartedAt  *time.Time         `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+}
@@ -6,9 +6,89 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// จุดประสงค์ของ OTP: รหัสที่ออกให้จุดประสงค์หนึ่งใช้กับจุดประสงค์อื่นไม่ได้
+const (
+	OTPPurposePasswordReset   = "password_reset"
+	OTPPurposeEmailChange     = "email_change"
+	OTPPurposeAccountDeletion = "account_deletion"
+	OTPPurposeEmailVerify     = "email_verification"
+	// OTPPurposeResetTicket ไม่ได้ส่งทางอีเมล แต่เป็น ticket ที่ได้จาก VerifyOTP
+	// เพื่อใช้กับ ResetPassword แทนการส่ง OTP ซ้ำ
+	OTPPurposeResetTicket = "password_reset_ticket"
+	// OTPPurposeTwoFactorLogin คือ ticket ที่ Login ออกให้เมื่อรหัสผ่านถูกและต้องยืนยัน 2FA ต่อ
+	OTPPurposeTwoFactorLogin = "two_factor_login"
+	// OTPPurposeTelegramLink คือ token ใน deep-link ของบอท Telegram สำหรับผูกแชทกับบัญชี
+	// ผูกกับ ID ของผู้ใช้ (เก็บในช่อง Email) เพราะอีเมลเปลี่ยนได้
+	OTPPurposeTelegramLink = "telegram_link"
+	// OTPPurposeLineLink คือรหัสที่ผู้ใช้ส่งให้บอท LINE ("link <code>") เพื่อผูกบัญชี LINE กับบัญชี
+	// ผูกกับ ID ของผู้ใช้เหมือน OTPPurposeTelegramLink
+	OTPPurposeLineLink = "line_link"
+)
+
+const (
+	// OTPTTL คืออายุของรหัส OTP ที่ส่งทางอีเมล
+	OTPTTL = 3 * time.Minute
+	// EmailVerifyTTL คืออายุของ OTP ยืนยันอีเมล (นานกว่า OTP อื่นเพราะผู้ใช้อาจเปิดอีเมลช้า)
+	EmailVerifyTTL = 30 * time.Minute
+	// ResetTicketTTL คืออายุของ reset ticket
+	ResetTicketTTL = 10 * time.Minute
+	// TwoFactorTicketTTL คืออายุของ ticket ระหว่างขั้นตอนที่สองของการ login
+	TwoFactorTicketTTL = 5 * time.Minute
+	// TelegramLinkTTL คืออายุของลิงก์ผูกบัญชี Telegram
+	TelegramLinkTTL = 10 * time.Minute
+	// LineLinkTTL คืออายุของรหัสผูกบัญชี LINE
+	LineLinkTTL = 10 * time.Minute
+	// MaxOTPAttempts คือจำนวนครั้งที่ใส่ OTP ผิดได้ก่อนที่รหัสจะถูกยกเลิก
+	MaxOTPAttempts = 5
+)
+
+// OTP เก็บเฉพาะ hash ของรหัส ผูกกับอีเมลและจุดประสงค์ และใช้ได้ครั้งเดียว
 type OTP struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Email     string             `bson:"email" json:"email"`
-	NumberOTP string             `bson:"number_otp" json:"number_otp"`
-	DateOTP   time.Time          `bson:"date_otp" json:"date_otp"`
+	Purpose   string             `bson:"purpose" json:"purpose"`
+	CodeHash  string             `bson:"code_hash" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expiresAt"`
+	Attempts  int                `bson:"attempts" json:"attempts"`
+}
+
+// OTPLifetime returns how long a code of the given purpose stays valid
+func OTPLifetime(purpose string) time.Duration {
+	switch purpose {
+	case OTPPurposeEmailVerify:
+		return EmailVerifyTTL
+	case OTPPurposeResetTicket:
+		return ResetTicketTTL
+	case OTPPurposeTwoFactorLogin:
+		return TwoFactorTicketTTL
+	case OTPPurposeTelegramLink:
+		return TelegramLinkTTL
+	case OTPPurposeLineLink:
+		return LineLinkTTL
+	default:
+		return OTPTTL
+	}
+}
+
+// VerifyEmailRequest ใช้กับ POST /api/auth/verify-email
+type VerifyEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	OTP   string `json:"otp" binding:"required"`
+}
+
+// ResendVerificationRequest ใช้กับ POST /api/auth/resend-verification
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// RequestOTPRequest ใช้กับ POST /api/users/me/otp
+type RequestOTPRequest struct {
+	Purpose string `json:"purpose" binding:"required,oneof=email_change account_deletion"`
+}
+
+// ChangeEmailRequest ใช้กับ POST /api/users/me/email
+type ChangeEmailRequest struct {
+	NewEmail string `json:"newEmail" binding:"required,email"`
+	OTP      string `json:"otp" binding:"required"`
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// สถานะของข้อความใน outbox
+const (
+	OutboxPending = "pending"
+	OutboxSending = "sending"
+	OutboxSent    = "sent"
+	// OutboxDead คือข้อความที่ส่งไม่สำเร็จจนครบจำนวนครั้ง รอ admin สั่งส่งใหม่
+	OutboxDead = "dead"
+	// OutboxExpired คือข้อความที่หมดอายุก่อนส่งได้ (เช่นอีเมล OTP) จะไม่ถูกส่งอีก
+	OutboxExpired = "expired"
+)
+
+// OutboxMessage คือการแจ้งเตือนหนึ่งข้อความที่รอส่งผ่านช่องทางเดียว
+// เนื้อความไม่ถูกส่งออกทาง API (อาจมีรหัส OTP) และถูกลบทิ้งเมื่อส่งสำเร็จหรือหมดอายุ
+type OutboxMessage struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Channel string             `bson:"channel" json:"channel"`
+	// IdempotencyKey ไม่ซ้ำกันทั้ง collection ข้อความที่มี key ซ้ำจะถูกข้าม
+	IdempotencyKey string     `bson:"idempotency_key" json:"idempotencyKey"`
+	To             string     `bson:"to,omitempty" json:"to,omitempty"`
+	Subject        string     `bson:"subject,omitempty" json:"subject,omitempty"`
+	Text           string     `bson:"text,omitempty" json:"-"`
+	HTML           string     `bson:"html,omitempty" json:"-"`
+	Status         string     `bson:"status" json:"status"`
+	Attempts       int        `bson:"attempts" json:"attempts"`
+	LastError      string     `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	NextAttemptAt  time.Time  `bson:"next_attempt_at" json:"nextAttemptAt"`
+	LockedUntil    *time.Time `bson:"locked_until,omitempty" json:"-"`
+	ExpiresAt      *time.Time `bson:"expires_at,omitempty" json:"expiresAt,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at" json:"createdAt"`
+	UpdatedAt      time.Time  `bson:"updated_at" json:"updatedAt"`
+	SentAt         *time.Time `bson:"sent_at,omitempty" json:"sentAt,omitempty"`
+	// DiscardAt คือเวลาที่ข้อความ dead หรือ expired ถูกลบทิ้ง (TTL)
+	DiscardAt *time.Time `bson:"discard_at,omitempty" json:"discardAt,omitempty"`
+}
+
+// Expired บอกว่าข้อความหมดอายุแล้ว ณ เวลา now
+func (m *OutboxMessage) Expired(now time.Time) bool {
+	return m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
+}
+
+// OutboxSearch describes GET /api/admin/outbox
+type OutboxSearch struct {
+	Status  string
+	Channel string
+	Page    int
+	Limit   int
+}
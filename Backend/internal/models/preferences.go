@@ -0,0 +1,232 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ช่องทางส่วนตัวที่ผู้ใช้รับการแจ้งเตือนได้ (ตรงกับ Name() ของ notifier)
+const (
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+	ChannelLine     = "line"
+)
+
+// Channels lists the personal channels in the order they are tried
+var Channels = []string{ChannelEmail, ChannelTelegram, ChannelLine}
+
+// เหตุการณ์ที่ผู้ใช้เลือกรับการแจ้งเตือนได้
+const (
+	EventNewDraw   = "new_draw"
+	EventTicketWon = "ticket_won"
+	// EventWeeklyDigest คือสรุปประจำสัปดาห์ ส่งทุกวันจันทร์ (ดู jobs.WeeklyDigest)
+	EventWeeklyDigest = "weekly_digest"
+	// EventSecurityAlert คือการเปลี่ยนแปลงสำคัญของบัญชี เช่นเปลี่ยนรหัสผ่าน ไม่ถูกเลื่อนด้วยช่วงเวลางดแจ้งเตือน
+	EventSecurityAlert = "security_alert"
+)
+
+// ภาษาของข้อความแจ้งเตือน
+const (
+	LanguageThai    = "th"
+	LanguageEnglish = "en"
+)
+
+// Preferences คือการตั้งค่าการแจ้งเตือนของผู้ใช้หนึ่งคน (collection user_preferences)
+// ผู้ใช้ที่ยังไม่เคยตั้งค่าใช้ DefaultPreferences
+type Preferences struct {
+	ID         primitive.ObjectID   `bson:"_id,omitempty" json:"-"`
+	UserID     string               `bson:"user_id" json:"-"`
+	Channels   NotificationChannels `bson:"channels" json:"channels"`
+	Events     NotificationEvents   `bson:"events" json:"events"`
+	QuietHours QuietHours           `bson:"quiet_hours" json:"quietHours"`
+	Language   string               `bson:"language" json:"language"`
+	UpdatedAt  time.Time            `bson:"updated_at" json:"updatedAt"`
+}
+
+// NotificationChannels บอกว่าช่องทางไหนเปิดรับการแจ้งเตือน
+type NotificationChannels struct {
+	Email    bool `bson:"email" json:"email"`
+	Telegram bool `bson:"telegram" json:"telegram"`
+	Line     bool `bson:"line" json:"line"`
+}
+
+// NotificationEvents บอกว่าเหตุการณ์ไหนที่ผู้ใช้อยากได้รับแจ้ง
+type NotificationEvents struct {
+	NewDraw        bool `bson:"new_draw" json:"newDraw"`
+	TicketWon      bool `bson:"ticket_won" json:"ticketWon"`
+	WeeklyDigest   bool `bson:"weekly_digest" json:"weeklyDigest"`
+	SecurityAlerts bool `bson:"security_alerts" json:"securityAlerts"`
+}
+
+// QuietHours คือช่วงเวลา (เวลาประเทศไทย รูปแบบ HH:MM) ที่ไม่ส่งการแจ้งเตือน
+// ข้อความที่เกิดในช่วงนี้จะถูกส่งเมื่อช่วงเวลาสิ้นสุด ช่วงเวลาข้ามเที่ยงคืนได้ เช่น 22:00-07:00
+type QuietHours struct {
+	Enabled bool   `bson:"enabled" json:"enabled"`
+	Start   string `bson:"start" json:"start"`
+	End     string `bson:"end" json:"end"`
+}
+
+// DefaultPreferences: อีเมลภาษาไทย แจ้งเมื่อถูกรางวัลและแจ้งเตือนความปลอดภัย
+func DefaultPreferences(userID string) *Preferences {
+	return &Preferences{
+		UserID:     userID,
+		Channels:   NotificationChannels{Email: true},
+		Events:     NotificationEvents{TicketWon: true, SecurityAlerts: true},
+		QuietHours: QuietHours{Start: "22:00", End: "07:00"},
+		Language:   LanguageThai,
+	}
+}
+
+// Wants reports whether the user wants to hear about event
+func (p *Preferences) Wants(event string) bool {
+	switch event {
+	case EventNewDraw:
+		return p.Events.NewDraw
+	case EventTicketWon:
+		return p.Events.TicketWon
+	case EventWeeklyDigest:
+		return p.Events.WeeklyDigest
+	case EventSecurityAlert:
+		return p.Events.SecurityAlerts
+	}
+	return false
+}
+
+// ChannelEnabled reports whether the user turned channel on
+func (p *Preferences) ChannelEnabled(channel string) bool {
+	switch channel {
+	case ChannelEmail:
+		return p.Channels.Email
+	case ChannelTelegram:
+		return p.Channels.Telegram
+	case ChannelLine:
+		return p.Channels.Line
+	}
+	return false
+}
+
+// SetChannel turns channel on or off. Email is turned back on when no
+// channel would be left
+func (p *Preferences) SetChannel(channel string, enabled bool) {
+	switch channel {
+	case ChannelEmail:
+		p.Channels.Email = enabled
+	case ChannelTelegram:
+		p.Channels.Telegram = enabled
+	case ChannelLine:
+		p.Channels.Line = enabled
+	}
+	if !p.anyChannel() {
+		p.Channels.Email = true
+	}
+}
+
+func (p *Preferences) anyChannel() bool {
+	return p.Channels.Email || p.Channels.Telegram || p.Channels.Line
+}
+
+// QuietUntil returns when the quiet hours around t end, or the zero time if
+// t is outside them. Start and End are read in t's location
+func (q QuietHours) QuietUntil(t time.Time) time.Time {
+	if !q.Enabled {
+		return time.Time{}
+	}
+	start, err1 := parseClock(q.Start)
+	end, err2 := parseClock(q.End)
+	if err1 != nil || err2 != nil || start == end {
+		return time.Time{}
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	quiet := now >= start && now < end
+	if start > end {
+		quiet = now >= start || now < end
+	}
+	if !quiet {
+		return time.Time{}
+	}
+
+	until := time.Date(t.Year(), t.Month(), t.Day(), end/60, end%60, 0, 0, t.Location())
+	if !until.After(t) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// parseClock แปลง "HH:MM" เป็นจำนวนนาทีนับจากเที่ยงคืน
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("เวลาต้องอยู่ในรูปแบบ HH:MM (%q)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// UpdatePreferencesRequest ใช้กับ PATCH /api/users/me/preferences
+// ส่งมาเฉพาะค่าที่ต้องการเปลี่ยน ค่าที่ไม่ได้ส่งคงเดิม
+type UpdatePreferencesRequest struct {
+	Channels *struct {
+		Email    *bool `json:"email"`
+		Telegram *bool `json:"telegram"`
+		Line     *bool `json:"line"`
+	} `json:"channels"`
+	Events *struct {
+		NewDraw        *bool `json:"newDraw"`
+		TicketWon      *bool `json:"ticketWon"`
+		WeeklyDigest   *bool `json:"weeklyDigest"`
+		SecurityAlerts *bool `json:"securityAlerts"`
+	} `json:"events"`
+	QuietHours *struct {
+		Enabled *bool   `json:"enabled"`
+		Start   *string `json:"start"`
+		End     *string `json:"end"`
+	} `json:"quietHours"`
+	Language *string `json:"language" binding:"omitempty,oneof=th en"`
+}
+
+// Apply copies the fields that were sent onto p and checks the result
+func (r *UpdatePreferencesRequest) Apply(p *Preferences) error {
+	if ch := r.Channels; ch != nil {
+		setBool(&p.Channels.Email, ch.Email)
+		setBool(&p.Channels.Telegram, ch.Telegram)
+		setBool(&p.Channels.Line, ch.Line)
+	}
+	if ev := r.Events; ev != nil {
+		setBool(&p.Events.NewDraw, ev.NewDraw)
+		setBool(&p.Events.TicketWon, ev.TicketWon)
+		setBool(&p.Events.WeeklyDigest, ev.WeeklyDigest)
+		setBool(&p.Events.SecurityAlerts, ev.SecurityAlerts)
+	}
+	if q := r.QuietHours; q != nil {
+		setBool(&p.QuietHours.Enabled, q.Enabled)
+		if q.Start != nil {
+			p.QuietHours.Start = *q.Start
+		}
+		if q.End != nil {
+			p.QuietHours.End = *q.End
+		}
+	}
+	if r.Language != nil {
+		p.Language = *r.Language
+	}
+
+	if !p.anyChannel() {
+		return errors.New("กรุณาเปิดช่องทางการแจ้งเตือนอย่างน้อยหนึ่งช่องทาง")
+	}
+	if _, err := parseClock(p.QuietHours.Start); err != nil {
+		return err
+	}
+	if _, err := parseClock(p.QuietHours.End); err != nil {
+		return err
+	}
+	return nil
+}
+
+func setBool(dst *bool, v *bool) {
+	if v != nil {
+		*dst = *v
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// สถานะของงานตรวจรางวัลซ้ำ
+const (
+	RecheckStatusQueued    = "queued"
+	RecheckStatusRunning   = "running"
+	RecheckStatusCompleted = "completed"
+	RecheckStatusFailed    = "failed"
+)
+
+// RecheckJob คืองานเบื้องหลังที่ตรวจรางวัลสลากทุกใบของงวด PrizeDate ใหม่
+// หลังจากผลรางวัลงวดนั้นถูกเพิ่มหรือแก้ไข
+type RecheckJob struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PrizeDate  string             `bson:"prize_date" json:"prizeDate"`
+	Trigger    string             `bson:"trigger" json:"trigger"` // create, update, delete, rollback
+	Status     string             `bson:"status" json:"status"`
+	Total      int64              `bson:"total" json:"total"`
+	Processed  int64              `bson:"processed" json:"processed"`
+	Outcomes   RecheckOutcomes    `bson:"outcomes" json:"outcomes"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	StartedAt  *time.Time         `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+}
+
+// RecheckOutcomes นับผลการตรวจรางวัลของสลากแต่ละใบในงาน
+type RecheckOutcomes struct {
+	Win        int64            `bson:"win" json:"win"`
+	Lose       int64            `bson:"lose" json:"lose"`
+	Pending    int64            `bson:"pending" json:"pending"` // ยังไม่มีผลรางวัลของงวดนี้
+	Failed     int64            `bson:"failed" json:"failed"`
+	PrizeTypes map[string]int64 `bson:"prize_types" json:"prizeTypes"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken คือ refresh token ที่ออกให้ผู้ใช้ (เก็บเฉพาะ hash ของ token)
+// ทุกครั้งที่ refresh จะได้ token ใหม่ใน FamilyID เดิม และ token เดิมถูกเพิกถอน
+// ถ้ามีการใช้ token ที่ถูกเพิกถอนแล้วซ้ำ จะถือว่า token รั่วและเพิกถอนทั้ง family
+type RefreshToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	FamilyID   primitive.ObjectID `bson:"family_id" json:"familyId"`
+	TokenHash  string             `bson:"token_hash" json:"-"`
+	RememberMe bool               `bson:"remember_me" json:"rememberMe"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expiresAt"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+}
+
+// RefreshTokenRequest ใช้กับ POST /api/auth/refresh และ POST /api/auth/logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
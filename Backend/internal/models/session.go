@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session คือการเข้าสู่ระบบหนึ่งครั้งของผู้ใช้ (หนึ่งอุปกรณ์/เบราว์เซอร์)
+// ID ของ session ใช้เป็น FamilyID ของ refresh token และอยู่ใน claim "sid" ของ access token
+// เมื่อ session ถูกเพิกถอน access token ที่ยังไม่หมดอายุจะใช้ไม่ได้ทันทีใน request ถัดไป
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"-"`
+	Device     string             `bson:"device" json:"device"`
+	IP         string             `bson:"ip" json:"ip"`
+	UserAgent  string             `bson:"user_agent" json:"userAgent"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	LastSeenAt time.Time          `bson:"last_seen_at" json:"lastSeenAt"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expiresAt"`
+	RememberMe bool               `bson:"remember_me" json:"-"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"-"`
+
+	// Current บอกว่าเป็น session ของ request ปัจจุบัน (ไม่ได้เก็บในฐานข้อมูล)
+	Current bool `bson:"-" json:"current"`
+}
+
+// Active reports whether the session can still be used at the given time
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
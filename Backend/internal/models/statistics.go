@@ -2,10 +2,22 @@ package models
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// จำนวนเลขรางวัลในแต่ละรางวัลตามตารางรางวัลสลากกินแบ่งรัฐบาล
+const (
+	Prize2Count = 5
+	Prize3Count = 10
+	Prize4Count = 50
+	Prize5Count = 100
+)
+
 type Statistics struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Date      string             `bson:"date" json:"date"`
 	Prize1    string             `bson:"prize1" json:"prize1"`
+	Prize2    []string           `bson:"prize2,omitempty" json:"prize2,omitempty"`
+	Prize3    []string           `bson:"prize3,omitempty" json:"prize3,omitempty"`
+	Prize4    []string           `bson:"prize4,omitempty" json:"prize4,omitempty"`
+	Prize5    []string           `bson:"prize5,omitempty" json:"prize5,omitempty"`
 	First3One string             `bson:"first3_one" json:"first3_one"`
 	First3Two string             `bson:"first3_two" json:"first3_two"`
 	Last3One  string             `bson:"last3_one" json:"last3_one"`
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// เหตุการณ์ที่ทำให้เกิดเวอร์ชันใหม่ของผลรางวัล
+const (
+	// RevisionBaseline คือสภาพของผลรางวัลที่มีอยู่ก่อนเริ่มเก็บประวัติ บันทึกไว้ก่อนการแก้ไขครั้งแรก
+	RevisionBaseline   = "baseline"
+	RevisionCreated    = "created"
+	RevisionUpdated    = "updated"
+	RevisionDeleted    = "deleted"
+	RevisionRolledBack = "rolled_back"
+)
+
+// StatisticsRevision คือผลรางวัลหนึ่งงวด ณ เวอร์ชันหนึ่ง
+// Result ของเวอร์ชัน deleted คือผลรางวัลก่อนถูกลบ จึงย้อนกลับไปเวอร์ชันนั้นเพื่อกู้คืนได้
+type StatisticsRevision struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StatisticsID primitive.ObjectID `bson:"statistics_id" json:"statisticsId"`
+	Version      int                `bson:"version" json:"version"`
+	Action       string             `bson:"action" json:"action"`
+	Result       Statistics         `bson:"result" json:"result"`
+	// RestoredFrom คือเวอร์ชันที่ถูกย้อนกลับไป (เฉพาะ rolled_back)
+	RestoredFrom int       `bson:"restored_from,omitempty" json:"restoredFrom,omitempty"`
+	ActorID      string    `bson:"actor_id,omitempty" json:"actorId,omitempty"`
+	ActorEmail   string    `bson:"actor_email,omitempty" json:"actorEmail,omitempty"`
+	CreatedAt    time.Time `bson:"created_at" json:"createdAt"`
+}
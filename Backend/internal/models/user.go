@@ -7,14 +7,71 @@ import (
 )
 
 // User represents a user in the application
+// EmailVerified stays false until the user confirms the OTP sent on registration
+// The TOTP fields are only set when two-factor login is turned on; RecoveryCodes holds hashes
+// Suspended and PasswordResetRequired are set by admins and block login
+// TelegramChatID is the user's own chat with the bot, used for personal notifications
+// LineUserID is the user's LINE account (as seen by the LINE bot), used the same way
+// How the user wants to be notified is kept separately in Preferences
 type User struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name         string             `bson:"name" json:"name"`
-	Email        string             `bson:"email" json:"email"`
-	PasswordHash string             `bson:"password_hash" json:"-"`
-	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updatedAt"`
-	Role         string             `bson:"role" json:"role"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name          string             `bson:"name" json:"name"`
+	Email         string             `bson:"email" json:"email"`
+	PasswordHash  string             `bson:"password_hash" json:"-"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updatedAt"`
+	Role          string             `bson:"role" json:"role"`
+	EmailVerified bool               `bson:"email_verified" json:"emailVerified"`
+
+	TwoFactorEnabled  bool     `bson:"two_factor_enabled" json:"twoFactorEnabled"`
+	TOTPSecret        string   `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret string   `bson:"totp_pending_secret,omitempty" json:"-"`
+	TOTPLastStep      int64    `bson:"totp_last_step,omitempty" json:"-"`
+	RecoveryCodes     []string `bson:"recovery_codes,omitempty" json:"-"`
+
+	Suspended             bool       `bson:"suspended" json:"suspended"`
+	SuspendedAt           *time.Time `bson:"suspended_at,omitempty" json:"suspendedAt,omitempty"`
+	SuspendReason         string     `bson:"suspend_reason,omitempty" json:"suspendReason,omitempty"`
+	PasswordResetRequired bool       `bson:"password_reset_required" json:"passwordResetRequired"`
+
+	TelegramChatID string `bson:"telegram_chat_id,omitempty" json:"-"`
+	LineUserID     string `bson:"line_user_id,omitempty" json:"-"`
+}
+
+// Recipient returns the address of the user on a notification channel, or
+// "" when the user can't be reached there
+func (u *User) Recipient(channel string) string {
+	switch channel {
+	case ChannelEmail:
+		return u.Email
+	case ChannelTelegram:
+		return u.TelegramChatID
+	case ChannelLine:
+		return u.LineUserID
+	}
+	return ""
+}
+
+// Roles
+// Roles; what each role may do is defined in the permissions package
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+	// RoleResultsEditor may publish draw results but can't manage users
+	RoleResultsEditor = "results_editor"
+)
+
+// Roles lists every valid role
+var Roles = []string{RoleUser, RoleAdmin, RoleResultsEditor}
+
+// IsValidRole reports whether role is one of Roles
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
 
 // UserLogin represents the login credentials
@@ -22,6 +79,29 @@ type UserLogin struct {
 	Email      string `json:"email" binding:"required,email"`
 	Password   string `json:"password" binding:"required,min=6"`
 	RememberMe bool   `json:"rememberMe"`
+	// Device ชื่ออุปกรณ์ที่แสดงในรายการ session (ถ้าไม่ส่งมาจะเดาจาก User-Agent)
+	Device string `json:"device"`
+}
+
+// TwoFactorLoginRequest is the second step of a login with two-factor authentication
+// Code is a TOTP code or a recovery code
+type TwoFactorLoginRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Ticket     string `json:"twoFactorTicket" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	RememberMe bool   `json:"rememberMe"`
+	Device     string `json:"device"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code, e.g. to confirm 2FA setup
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest turns 2FA off; Code is a TOTP code or a recovery code
+type DisableTwoFactorRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required,min=6"`
+	Code            string `json:"code" binding:"required"`
 }
 
 // UserRegistration represents the user registration data
@@ -36,11 +116,17 @@ type UserRegistration struct {
 
 // UserResponse represents the user data returned in API responses
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"createdAt"`
-	Role      string    `json:"role"`
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	CreatedAt        time.Time `json:"createdAt"`
+	Role             string    `json:"role"`
+	EmailVerified    bool      `json:"emailVerified"`
+	TwoFactorEnabled bool      `json:"twoFactorEnabled"`
+	TelegramLinked   bool      `json:"telegramLinked"`
+	LineLinked       bool      `json:"lineLinked"`
+	// Permissions are filled in by the handlers from the user's role
+	Permissions []string `json:"permissions"`
 }
 
 // UpdateUserRequest สำหรับ PATCH /api/users/me
@@ -56,17 +142,23 @@ type ChangePasswordRequest struct {
 }
 
 // DeleteAccountRequest represents the account deletion request
+// Either CurrentPassword or an account deletion OTP is required
 type DeleteAccountRequest struct {
-	CurrentPassword string `json:"currentPassword" binding:"required,min=6"`
+	CurrentPassword string `json:"currentPassword" binding:"omitempty,min=6"`
+	OTP             string `json:"otp"`
 }
 
 // ToResponse converts a User to UserResponse
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID.Hex(),
-		Name:      u.Name,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
-		Role:      u.Role,
+		ID:               u.ID.Hex(),
+		Name:             u.Name,
+		Email:            u.Email,
+		CreatedAt:        u.CreatedAt,
+		Role:             u.Role,
+		EmailVerified:    u.EmailVerified,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		TelegramLinked:   u.TelegramChatID != "",
+		LineLinked:       u.LineUserID != "",
 	}
 }
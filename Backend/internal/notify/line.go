@@ -0,0 +1,42 @@
+package notify
+
+import "context"
+
+const linePushURL = "https://api.line.me/v2/bot/message/push"
+
+// LINE sends push messages through the LINE Messaging API
+type LINE struct {
+	channelToken string
+	to           string
+}
+
+// NewLINE creates a LINE channel. to is the default user, group or room ID
+func NewLINE(channelToken, to string) *LINE {
+	return &LINE{channelToken: channelToken, to: to}
+}
+
+// Name returns "line"
+func (l *LINE) Name() string {
+	return "line"
+}
+
+// Notify pushes msg.Text as a text message. LINE has no markup, so HTML is ignored
+func (l *LINE) Notify(ctx context.Context, msg Message) error {
+	to := msg.To
+	if to == "" {
+		to = l.to
+	}
+	if to == "" {
+		return ErrNoRecipient
+	}
+
+	payload := map[string]interface{}{
+		"to": to,
+		"messages": []map[string]string{
+			{"type": "text", "text": msg.Text},
+		},
+	}
+	return postJSON(ctx, linePushURL, payload, map[string]string{
+		"Authorization": "Bearer " + l.channelToken,
+	})
+}
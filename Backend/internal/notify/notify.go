@@ -0,0 +1,116 @@
+// Package notify sends notifications over Telegram, email (SMTP), LINE and
+// HTTP webhooks behind one Notifier interface.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Message is one notification
+type Message struct {
+	// To is the recipient in the channel's own terms: an email address, a
+	// Telegram chat ID or a LINE user/group ID. Empty means the channel's
+	// default recipient, if it has one
+	To string
+	// Subject is used by email and webhooks; chat channels only send the body
+	Subject string
+	// Text is the plain text body; every channel can send it
+	Text string
+	// HTML is an optional formatted body (Telegram HTML subset). Channels
+	// that can't render it send Text instead
+	HTML string
+	// Key identifies the message for queued delivery: a queued channel sends
+	// a message with the same Key only once. Empty means always send
+	Key string
+	// ExpiresAt, when set, is the time after which a queued message is
+	// dropped instead of delivered late (e.g. an OTP email)
+	ExpiresAt time.Time
+	// NotBefore, when set, holds a queued message back until that time
+	// (e.g. the end of the recipient's quiet hours)
+	NotBefore time.Time
+}
+
+// Notifier delivers messages over one channel
+type Notifier interface {
+	// Name identifies the channel in logs and errors
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// ErrNoRecipient is returned when a message has no To and the channel has
+// no default recipient
+var ErrNoRecipient = errors.New("notify: no recipient")
+
+// httpClient is shared by the HTTP based channels
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Dispatcher fans a message out to several notifiers at once
+type Dispatcher struct {
+	notifiers []Notifier
+}
+
+// NewDispatcher creates a dispatcher over notifiers. With none, Notify does nothing
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{notifiers: notifiers}
+}
+
+// Name lists the channels of the dispatcher
+func (d *Dispatcher) Name() string {
+	names := make([]string, len(d.notifiers))
+	for i, n := range d.notifiers {
+		names[i] = n.Name()
+	}
+	return fmt.Sprint(names)
+}
+
+// Notify sends msg over every channel in parallel. A failing channel doesn't
+// stop the others; the errors of all failed channels are joined
+func (d *Dispatcher) Notify(ctx context.Context, msg Message) error {
+	errs := make([]error, len(d.notifiers))
+	var wg sync.WaitGroup
+	for i, n := range d.notifiers {
+		wg.Add(1)
+		go func(i int, n Notifier) {
+			defer wg.Done()
+			if err := n.Notify(ctx, msg); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", n.Name(), err)
+			}
+		}(i, n)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Recorder is a Notifier that keeps messages instead of sending them, for tests
+// and local development
+type Recorder struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Name returns "recorder"
+func (r *Recorder) Name() string {
+	return "recorder"
+}
+
+// Notify records msg
+func (r *Recorder) Notify(ctx context.Context, msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the recorded messages
+func (r *Recorder) Messages() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Message, len(r.messages))
+	copy(out, r.messages)
+	return out
+}
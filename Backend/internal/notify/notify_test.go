@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failing is a Notifier that always returns err
+type failing struct {
+	name string
+	err  error
+}
+
+func (f failing) Name() string { return f.name }
+
+func (f failing) Notify(ctx context.Context, msg Message) error { return f.err }
+
+func TestDispatcherSendsToEveryChannel(t *testing.T) {
+	first, second := &Recorder{}, &Recorder{}
+	d := NewDispatcher(first, second)
+
+	msg := Message{To: "a@example.com", Subject: "งวดใหม่", Text: "ผลรางวัล"}
+	if err := d.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	for i, r := range []*Recorder{first, second} {
+		got := r.Messages()
+		if len(got) != 1 || got[0] != msg {
+			t.Errorf("recorder %d got %+v, want [%+v]", i, got, msg)
+		}
+	}
+}
+
+func TestDispatcherJoinsErrors(t *testing.T) {
+	errDown := errors.New("down")
+	rec := &Recorder{}
+	d := NewDispatcher(failing{"telegram", errDown}, rec, failing{"line", ErrNoRecipient})
+
+	err := d.Notify(context.Background(), Message{Text: "hi"})
+	if !errors.Is(err, errDown) || !errors.Is(err, ErrNoRecipient) {
+		t.Fatalf("err = %v, want both channel errors", err)
+	}
+	if !strings.Contains(err.Error(), "telegram: down") || !strings.Contains(err.Error(), "line: ") {
+		t.Errorf("err = %q, want the channel names", err)
+	}
+	// ช่องทางที่ล้มเหลวไม่ทำให้ช่องทางอื่นไม่ได้ส่ง
+	if len(rec.Messages()) != 1 {
+		t.Errorf("recorder got %d messages, want 1", len(rec.Messages()))
+	}
+}
+
+func TestDispatcherWithoutChannels(t *testing.T) {
+	d := NewDispatcher()
+	if err := d.Notify(context.Background(), Message{Text: "hi"}); err != nil {
+		t.Errorf("Notify: %v", err)
+	}
+	if d.Name() != "[]" {
+		t.Errorf("Name() = %q", d.Name())
+	}
+}
+
+func TestSMTPHonoursContext(t *testing.T) {
+	// เซิร์ฟเวอร์ที่รับการเชื่อมต่อแต่ไม่ตอบอะไรเลย
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	s := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "support@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := s.Notify(ctx, Message{To: "a@example.com", Text: "hi"}); err == nil {
+		t.Fatal("Notify succeeded against a silent server")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Notify took %v, want it to stop at the ctx deadline", elapsed)
+	}
+
+	if err := s.Notify(context.Background(), Message{}); !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("err = %v, want ErrNoRecipient", err)
+	}
+}
+
+func TestHTTPErrorsDoNotLeakURLs(t *testing.T) {
+	const secret = "SECRET-TOKEN"
+	// ยกเลิกไว้ก่อน คำขอจึงล้มเหลวโดยไม่ต้องต่อเครือข่าย
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	channels := []Notifier{
+		NewTelegram("123456:"+secret, "1"),
+		NewLINE(secret, "U1"),
+		NewWebhook("https://hooks.example.com/"+secret, ""),
+	}
+	for _, n := range channels {
+		err := n.Notify(ctx, Message{Text: "hi"})
+		if err == nil {
+			t.Fatalf("%s: Notify succeeded with a cancelled context", n.Name())
+		}
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("%s: error leaks the secret: %v", n.Name(), err)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: err = %v, want context.Canceled", n.Name(), err)
+		}
+	}
+
+	err := NewWebhook("http://hooks.example.com:port/"+secret, "").Notify(context.Background(), Message{Text: "hi"})
+	if err == nil || strings.Contains(err.Error(), secret) {
+		t.Errorf("invalid URL: err = %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// smtpTimeout bounds a whole SMTP conversation when ctx has no deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig configures the email channel
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender address; it is shown as "Lotterich Support <From>"
+	From string
+}
+
+// SMTP sends messages as email
+type SMTP struct {
+	cfg SMTPConfig
+}
+
+// NewSMTP creates an email channel
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+// Name returns "email"
+func (s *SMTP) Name() string {
+	return "email"
+}
+
+// Notify emails msg to msg.To. The plain text is always sent; HTML is added as
+// an alternative part when present
+func (s *SMTP) Notify(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", "Lotterich Support <"+s.cfg.From+">")
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/plain", msg.Text)
+	if msg.HTML != "" {
+		m.AddAlternative("text/html", msg.HTML)
+	}
+	return s.send(ctx, msg.To, m)
+}
+
+// send delivers m over one SMTP connection. The connection is dialled with ctx
+// and gets ctx's deadline (or smtpTimeout), and cancelling ctx aborts it, so a
+// stuck server can't hold up the outbox worker. Like gomail, port 465 uses
+// implicit TLS and other ports use STARTTLS when the server offers it
+func (s *SMTP) send(ctx context.Context, to string, m *gomail.Message) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host}
+	if s.cfg.Port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if s.cfg.Port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if s.cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := m.WriteTo(w); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	// the message has been accepted; a failed QUIT doesn't mean it wasn't sent
+	c.Quit()
+	return nil
+}
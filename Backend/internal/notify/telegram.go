@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// Telegram sends messages through a Telegram bot
+type Telegram struct {
+	token  string
+	chatID string
+}
+
+// NewTelegram creates a Telegram channel. chatID is the default chat
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{token: token, chatID: chatID}
+}
+
+// Name returns "telegram"
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+// Notify sends msg.HTML with HTML parse mode, or msg.Text when there is no HTML
+func (t *Telegram) Notify(ctx context.Context, msg Message) error {
+	chatID := msg.To
+	if chatID == "" {
+		chatID = t.chatID
+	}
+	if chatID == "" {
+		return ErrNoRecipient
+	}
+
+	payload := map[string]string{
+		"chat_id": chatID,
+		"text":    msg.Text,
+	}
+	if msg.HTML != "" {
+		payload["text"] = msg.HTML
+		payload["parse_mode"] = "HTML"
+	}
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.token)
+	return postJSON(ctx, url, payload, nil)
+}
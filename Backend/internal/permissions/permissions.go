@@ -0,0 +1,57 @@
+// Package permissions maps roles to the permissions that routes require.
+package permissions
+
+import "github.com/user/Lotterich/internal/models"
+
+// Permissions
+const (
+	// AdminPanel lets a user open the admin area at all
+	AdminPanel      = "admin:panel"
+	StatisticsRead  = "statistics:read"
+	StatisticsWrite = "statistics:write"
+	DrawsWrite      = "draws:write"
+	UsersRead       = "users:read"
+	UsersWrite      = "users:write"
+	AuditRead       = "audit:read"
+	OutboxRead      = "outbox:read"
+	OutboxWrite     = "outbox:write"
+)
+
+// All lists every permission
+var All = []string{AdminPanel, StatisticsRead, StatisticsWrite, DrawsWrite, UsersRead, UsersWrite, AuditRead, OutboxRead, OutboxWrite}
+
+// byRole is the permission set of every role. Roles not listed have none
+var byRole = map[string][]string{
+	models.RoleAdmin: All,
+	models.RoleResultsEditor: {
+		AdminPanel,
+		StatisticsRead,
+		StatisticsWrite,
+		DrawsWrite,
+	},
+}
+
+// For returns the permissions of a role
+func For(role string) []string {
+	perms := byRole[role]
+	out := make([]string, len(perms))
+	copy(out, perms)
+	return out
+}
+
+// HasAll reports whether granted contains every one of required
+func HasAll(granted []string, required ...string) bool {
+	for _, r := range required {
+		found := false
+		for _, g := range granted {
+			if g == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package prize
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+)
+
+// ConfigVersion คือเวอร์ชันของรูปแบบไฟล์กติกาที่ Engine อ่านได้
+const ConfigVersion = 1
+
+// ErrNoRuleSet ถูกคืนเมื่อไม่มี RuleSet ที่ครอบคลุมวันที่ออกรางวัล
+var ErrNoRuleSet = errors.New("no prize rule set for draw date")
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Config คือรูปแบบไฟล์กติกาเงินรางวัล
+type Config struct {
+	Version  int       `json:"version"`
+	RuleSets []RuleSet `json:"rule_sets"`
+}
+
+// Engine เลือก RuleSet ตามวันที่ออกรางวัลแล้วใช้ตรวจรางวัล
+type Engine struct {
+	ruleSets []RuleSet // เรียงตาม EffectiveFrom จากเก่าไปใหม่
+}
+
+// NewEngine สร้าง Engine จาก Config หลังจากตรวจสอบความถูกต้องแล้ว
+func NewEngine(cfg Config) (*Engine, error) {
+	if cfg.Version != ConfigVersion {
+		return nil, fmt.Errorf("unsupported prize config version %d", cfg.Version)
+	}
+	if len(cfg.RuleSets) == 0 {
+		return nil, errors.New("prize config has no rule sets")
+	}
+
+	known := make(map[string]bool, len(Types))
+	for _, t := range Types {
+		known[t] = true
+	}
+	seen := make(map[string]bool, len(cfg.RuleSets))
+	ruleSets := make([]RuleSet, 0, len(cfg.RuleSets))
+	for _, rs := range cfg.RuleSets {
+		if _, err := time.Parse("2006-01-02", rs.EffectiveFrom); err != nil {
+			return nil, fmt.Errorf("rule set %q: invalid effective_from %q", rs.Name, rs.EffectiveFrom)
+		}
+		if seen[rs.EffectiveFrom] {
+			return nil, fmt.Errorf("rule set %q: duplicate effective_from %s", rs.Name, rs.EffectiveFrom)
+		}
+		seen[rs.EffectiveFrom] = true
+		for prizeType, amount := range rs.Amounts {
+			if !known[prizeType] {
+				return nil, fmt.Errorf("rule set %q: unknown prize type %q", rs.Name, prizeType)
+			}
+			if amount < 0 {
+				return nil, fmt.Errorf("rule set %q: negative amount for %s", rs.Name, prizeType)
+			}
+		}
+		ruleSets = append(ruleSets, rs)
+	}
+	sort.Slice(ruleSets, func(i, j int) bool {
+		return ruleSets[i].EffectiveFrom < ruleSets[j].EffectiveFrom
+	})
+	return &Engine{ruleSets: ruleSets}, nil
+}
+
+// Parse อ่าน Config จาก JSON แล้วสร้าง Engine
+func Parse(data []byte) (*Engine, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse prize config: %w", err)
+	}
+	return NewEngine(cfg)
+}
+
+// LoadFile อ่านไฟล์กติกาเงินรางวัลจาก path
+func LoadFile(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Default คืน Engine จากกติกาเงินรางวัลที่ฝังมากับโปรแกรม (rules.json)
+func Default() *Engine {
+	engine, err := Parse(defaultRules)
+	if err != nil {
+		panic(err)
+	}
+	return engine
+}
+
+// RuleSetFor คืน RuleSet ล่าสุดที่มีผลในวันที่ออกรางวัล drawDate (YYYY-MM-DD)
+func (e *Engine) RuleSetFor(drawDate string) (*RuleSet, error) {
+	if _, err := time.Parse("2006-01-02", drawDate); err != nil {
+		return nil, fmt.Errorf("invalid draw date %q", drawDate)
+	}
+	for i := len(e.ruleSets) - 1; i >= 0; i-- {
+		if e.ruleSets[i].EffectiveFrom <= drawDate {
+			return &e.ruleSets[i], nil
+		}
+	}
+	return nil, ErrNoRuleSet
+}
+
+// Check ตรวจเลขสลากกับผลรางวัลของงวด โดยใช้ RuleSet ของวันที่ออกรางวัลนั้น
+func (e *Engine) Check(ticketNumber string, stat *models.Statistics) (Result, error) {
+	rs, err := e.RuleSetFor(stat.Date)
+	if err != nil {
+		return Result{}, err
+	}
+	return rs.Check(ticketNumber, stat), nil
+}
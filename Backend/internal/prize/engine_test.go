@@ -0,0 +1,229 @@
+package prize
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/user/Lotterich/internal/models"
+)
+
+func testDraw(date string) *models.Statistics {
+	return &models.Statistics{
+		Date:      date,
+		Prize1:    "123456",
+		Prize2:    []string{"200001"},
+		Prize3:    []string{"300001"},
+		Prize4:    []string{"400001"},
+		Prize5:    []string{"500001"},
+		First3One: "777",
+		First3Two: "888",
+		Last3One:  "999",
+		Last3Two:  "456",
+		Last2:     "56",
+	}
+}
+
+func TestCheckTiers(t *testing.T) {
+	engine := Default()
+	stat := testDraw("2024-06-01")
+
+	tests := []struct {
+		name   string
+		ticket string
+		types  []string
+		amount int
+	}{
+		{"prize1 with last3 and last2", "123456", []string{TypePrize1, TypeLast3, TypeLast2}, 6000000 + 4000 + 2000},
+		{"near1 below", "123455", []string{TypeNear1}, 100000},
+		{"near1 above", "123457", []string{TypeNear1}, 100000},
+		{"prize2", "200001", []string{TypePrize2}, 200000},
+		{"prize3", "300001", []string{TypePrize3}, 80000},
+		{"prize4", "400001", []string{TypePrize4}, 40000},
+		{"prize5", "500001", []string{TypePrize5}, 20000},
+		{"first3 one", "777000", []string{TypeFirst3}, 4000},
+		{"first3 two", "888123", []string{TypeFirst3}, 4000},
+		{"last3", "000999", []string{TypeLast3}, 4000},
+		{"last2", "000056", []string{TypeLast2}, 2000},
+		{"first3 and last2", "777056", []string{TypeFirst3, TypeLast2}, 6000},
+		{"lose", "000000", nil, 0},
+		{"short number", "12345", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Check(tt.ticket, stat)
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if !reflect.DeepEqual(result.Types, tt.types) {
+				t.Errorf("types = %v, want %v", result.Types, tt.types)
+			}
+			if result.Amount != tt.amount {
+				t.Errorf("amount = %d, want %d", result.Amount, tt.amount)
+			}
+			if result.Won() != (len(tt.types) > 0) {
+				t.Errorf("Won() = %v", result.Won())
+			}
+		})
+	}
+}
+
+func TestCheckSkipsTypesWithoutAmount(t *testing.T) {
+	engine, err := NewEngine(Config{Version: ConfigVersion, RuleSets: []RuleSet{
+		{Name: "no-last2", EffectiveFrom: "2000-01-01", Amounts: map[string]int{TypeLast3: 4000}},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	result, err := engine.Check("123456", testDraw("2024-06-01"))
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if want := []string{TypeLast3}; !reflect.DeepEqual(result.Types, want) || result.Amount != 4000 {
+		t.Errorf("got %v %d, want %v 4000", result.Types, result.Amount, want)
+	}
+}
+
+func TestRuleSetFor(t *testing.T) {
+	// ใส่สลับลำดับไว้ เพื่อให้แน่ใจว่า NewEngine เรียงตาม EffectiveFrom
+	engine, err := NewEngine(Config{Version: ConfigVersion, RuleSets: []RuleSet{
+		{Name: "new", EffectiveFrom: "2025-01-01", Amounts: map[string]int{TypePrize1: 8000000}},
+		{Name: "old", EffectiveFrom: "2000-01-01", Amounts: map[string]int{TypePrize1: 6000000}},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	tests := []struct {
+		date    string
+		want    string
+		wantErr error
+	}{
+		{"1999-12-31", "", ErrNoRuleSet},
+		{"2000-01-01", "old", nil},
+		{"2024-12-31", "old", nil},
+		{"2025-01-01", "new", nil},
+		{"2025-01-02", "new", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			rs, err := engine.RuleSetFor(tt.date)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RuleSetFor: %v", err)
+			}
+			if rs.Name != tt.want {
+				t.Errorf("rule set = %q, want %q", rs.Name, tt.want)
+			}
+		})
+	}
+
+	if _, err := engine.RuleSetFor("01/01/2025"); err == nil {
+		t.Error("RuleSetFor accepted an invalid date")
+	}
+
+	// Check ใช้เงินรางวัลของชุดกติกาที่มีผลในวันที่ออกรางวัล
+	for date, want := range map[string]int{"2024-12-16": 6000000, "2025-01-16": 8000000} {
+		result, err := engine.Check("123456", testDraw(date))
+		if err != nil {
+			t.Fatalf("Check %s: %v", date, err)
+		}
+		if result.Amount != want || result.RuleSet == "" {
+			t.Errorf("Check %s: amount %d (%s), want %d", date, result.Amount, result.RuleSet, want)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{
+			name: "two versions",
+			json: `{"version": 1, "rule_sets": [
+				{"name": "standard", "effective_from": "2000-01-01", "amounts": {"prize1": 6000000, "last2": 2000}},
+				{"name": "2025", "effective_from": "2025-01-01", "amounts": {"prize1": 8000000, "last2": 3000}}
+			]}`,
+		},
+		{
+			name:    "unsupported version",
+			json:    `{"version": 2, "rule_sets": [{"name": "a", "effective_from": "2000-01-01", "amounts": {}}]}`,
+			wantErr: "unsupported prize config version",
+		},
+		{
+			name:    "no rule sets",
+			json:    `{"version": 1, "rule_sets": []}`,
+			wantErr: "no rule sets",
+		},
+		{
+			name: "overlapping versions",
+			json: `{"version": 1, "rule_sets": [
+				{"name": "a", "effective_from": "2025-01-01", "amounts": {"prize1": 6000000}},
+				{"name": "b", "effective_from": "2025-01-01", "amounts": {"prize1": 8000000}}
+			]}`,
+			wantErr: "duplicate effective_from",
+		},
+		{
+			name:    "bad effective_from",
+			json:    `{"version": 1, "rule_sets": [{"name": "a", "effective_from": "2025-13-01", "amounts": {}}]}`,
+			wantErr: "invalid effective_from",
+		},
+		{
+			name:    "unknown prize type",
+			json:    `{"version": 1, "rule_sets": [{"name": "a", "effective_from": "2000-01-01", "amounts": {"prize6": 1}}]}`,
+			wantErr: "unknown prize type",
+		},
+		{
+			name:    "negative amount",
+			json:    `{"version": 1, "rule_sets": [{"name": "a", "effective_from": "2000-01-01", "amounts": {"last2": -1}}]}`,
+			wantErr: "negative amount",
+		},
+		{
+			name:    "malformed json",
+			json:    `{"version": 1, "rule_sets": [`,
+			wantErr: "parse prize config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			engine, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			rs, err := engine.RuleSetFor("2025-06-01")
+			if err != nil || rs.Name != "2025" {
+				t.Errorf("RuleSetFor = %v, %v; want rule set 2025", rs, err)
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadFile accepted a missing file")
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	if _, err := Default().RuleSetFor("2024-06-01"); err != nil {
+		t.Fatalf("embedded rules: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Package prize ตรวจรางวัลสลากกินแบ่งรัฐบาลจากผลรางวัลของแต่ละงวด
+// โดยเงินรางวัลของแต่ละประเภทมาจากชุดกติกา (RuleSet) ที่ใช้ในงวดนั้น
+package prize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/user/Lotterich/internal/models"
+)
+
+// ประเภทรางวัล (ตรงกับค่า prize_type ที่บันทึกใน collection)
+const (
+	TypePrize1 = "prize1"
+	TypeNear1  = "near1"
+	TypePrize2 = "prize2"
+	TypePrize3 = "prize3"
+	TypePrize4 = "prize4"
+	TypePrize5 = "prize5"
+	TypeFirst3 = "first3"
+	TypeLast3  = "last3"
+	TypeLast2  = "last2"
+)
+
+// Types คือประเภทรางวัลทั้งหมด เรียงจากรางวัลใหญ่ไปเล็ก
+var Types = []string{
+	TypePrize1, TypeNear1, TypePrize2, TypePrize3, TypePrize4, TypePrize5,
+	TypeFirst3, TypeLast3, TypeLast2,
+}
+
+// Labels คือชื่อภาษาไทยของประเภทรางวัล
+var Labels = map[string]string{
+	TypePrize1: "รางวัลที่ 1",
+	TypeNear1:  "รางวัลข้างเคียงรางวัลที่ 1",
+	TypePrize2: "รางวัลที่ 2",
+	TypePrize3: "รางวัลที่ 3",
+	TypePrize4: "รางวัลที่ 4",
+	TypePrize5: "รางวัลที่ 5",
+	TypeFirst3: "เลขหน้า 3 ตัว",
+	TypeLast3:  "เลขท้าย 3 ตัว",
+	TypeLast2:  "เลขท้าย 2 ตัว",
+}
+
+// EnglishLabels คือชื่อภาษาอังกฤษของประเภทรางวัล
+var EnglishLabels = map[string]string{
+	TypePrize1: "1st prize",
+	TypeNear1:  "Next to the 1st prize",
+	TypePrize2: "2nd prize",
+	TypePrize3: "3rd prize",
+	TypePrize4: "4th prize",
+	TypePrize5: "5th prize",
+	TypeFirst3: "First 3 digits",
+	TypeLast3:  "Last 3 digits",
+	TypeLast2:  "Last 2 digits",
+}
+
+// RuleSet คือชุดกติกาเงินรางวัลที่ใช้กับงวดตั้งแต่ EffectiveFrom เป็นต้นไป
+type RuleSet struct {
+	Name          string         `json:"name"`
+	EffectiveFrom string         `json:"effective_from"` // YYYY-MM-DD
+	Amounts       map[string]int `json:"amounts"`        // ประเภทรางวัล -> เงินรางวัลต่อใบ (บาท)
+}
+
+// Result คือผลการตรวจรางวัลของสลากหนึ่งใบ
+type Result struct {
+	RuleSet string   `json:"ruleSet"`
+	Types   []string `json:"types"`  // ประเภทรางวัลที่ถูกทั้งหมด เรียงจากรางวัลใหญ่ไปเล็ก
+	Amount  int      `json:"amount"` // เงินรางวัลรวมต่อใบ
+}
+
+// Won บอกว่าสลากถูกรางวัลอย่างน้อยหนึ่งรางวัลหรือไม่
+func (r Result) Won() bool {
+	return len(r.Types) > 0
+}
+
+// PrimaryType คืนประเภทรางวัลที่ใหญ่ที่สุด หรือ "lose" ถ้าไม่ถูกรางวัล
+func (r Result) PrimaryType() string {
+	if !r.Won() {
+		return "lose"
+	}
+	return r.Types[0]
+}
+
+// ApplyTo บันทึกผลการตรวจรางวัลลงในรายการสลาก (prize_type, prize_types, prize_amount)
+func (r Result) ApplyTo(item *models.Collection) {
+	item.PrizeType = r.PrimaryType()
+	item.PrizeTypes = []string{}
+	if r.Won() {
+		item.PrizeTypes = r.Types
+	}
+	item.PrizeAmount = r.Amount
+}
+
+// Check ตรวจเลขสลากกับผลรางวัลตามกติกาของ RuleSet
+// สลากหนึ่งใบถูกได้หลายรางวัล และประเภทรางวัลที่ไม่มีใน Amounts จะไม่ถูกนับ
+func (rs *RuleSet) Check(ticketNumber string, stat *models.Statistics) Result {
+	result := Result{RuleSet: rs.Name}
+	win := func(prizeType string) {
+		amount, ok := rs.Amounts[prizeType]
+		if !ok || amount <= 0 {
+			return
+		}
+		result.Types = append(result.Types, prizeType)
+		result.Amount += amount
+	}
+
+	// รางวัลที่ 1
+	if ticketNumber == stat.Prize1 {
+		win(TypePrize1)
+	}
+	if len(ticketNumber) != 6 {
+		return result
+	}
+	// ข้างเคียงรางวัลที่ 1
+	if len(stat.Prize1) == 6 {
+		if n, p := toInt(ticketNumber), toInt(stat.Prize1); n == p+1 || n == p-1 {
+			win(TypeNear1)
+		}
+	}
+	// รางวัลที่ 2 - 5
+	for _, tier := range []struct {
+		prizeType string
+		numbers   []string
+	}{
+		{TypePrize2, stat.Prize2},
+		{TypePrize3, stat.Prize3},
+		{TypePrize4, stat.Prize4},
+		{TypePrize5, stat.Prize5},
+	} {
+		for _, number := range tier.numbers {
+			if ticketNumber == number {
+				win(tier.prizeType)
+			}
+		}
+	}
+	// สามตัวหน้า
+	for _, number := range []string{stat.First3One, stat.First3Two} {
+		if number != "" && ticketNumber[:3] == number {
+			win(TypeFirst3)
+		}
+	}
+	// สามตัวท้าย
+	for _, number := range []string{stat.Last3One, stat.Last3Two} {
+		if number != "" && ticketNumber[3:] == number {
+			win(TypeLast3)
+		}
+	}
+	// สองตัวท้าย
+	if stat.Last2 != "" && ticketNumber[4:] == stat.Last2 {
+		win(TypeLast2)
+	}
+	return result
+}
+
+func toInt(s string) int {
+	var n int
+	_, _ = fmt.Sscanf(s, "%d", &n)
+	return n
+}
+
+// FormatBaht ใส่จุลภาคคั่นหลักพันให้จำนวนเงิน เช่น 6000000 -> "6,000,000"
+func FormatBaht(amount int) string {
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
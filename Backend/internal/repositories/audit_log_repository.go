@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditLogRepository stores audit entries. It only appends; entries are never updated or deleted
+type AuditLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditLogRepository(db *mongo.Database) *AuditLogRepository {
+	collection := db.Collection("audit_log")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "created_at", Value: -1}}},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
+		panic(err)
+	}
+
+	// การสั่งส่งใหม่ในรุ่นก่อนคัดลอก error ที่อาจมี URL ของช่องทาง (token ของบอท Telegram) มาด้วย
+	if _, err := collection.UpdateMany(context.Background(),
+		bson.M{"action": models.AuditOutboxReplayed, "details.lastError": bson.M{"$regex": "https?://"}},
+		bson.M{"$set": bson.M{"details.lastError": "request failed (details removed)"}},
+	); err != nil {
+		panic(err)
+	}
+
+	return &AuditLogRepository{
+		collection: collection,
+	}
+}
+
+// Record appends an entry
+func (r *AuditLogRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+	entry.ID = primitive.NewObjectID()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// Search returns one page of entries, newest first, and the total number of matches
+func (r *AuditLogRepository) Search(ctx context.Context, search models.AuditSearch) ([]models.AuditEntry, int64, error) {
+	filter := bson.M{}
+	if search.ActorID != "" {
+		filter["actor_id"] = search.ActorID
+	}
+	if search.ActorEmail != "" {
+		filter["actor_email"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(search.ActorEmail) + "$", Options: "i"}
+	}
+	if group, ok := strings.CutSuffix(search.Action, ".*"); ok {
+		filter["action"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(group+".")}
+	} else if search.Action != "" {
+		filter["action"] = search.Action
+	}
+	if search.TargetType != "" {
+		filter["target_type"] = search.TargetType
+	}
+	if search.TargetID != "" {
+		filter["target_id"] = search.TargetID
+	}
+	createdAt := bson.M{}
+	if !search.From.IsZero() {
+		createdAt["$gte"] = search.From
+	}
+	if !search.To.IsZero() {
+		createdAt["$lt"] = search.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((search.Page - 1) * search.Limit)).
+		SetLimit(int64(search.Limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,251 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/user/Lotterich/internal/models"
+)
+
+// Collection list sort fields
+const (
+	SortByPurchaseDate = "purchaseDate"
+	SortByDrawDate     = "drawDate"
+	SortByPrizeAmount  = "prizeAmount"
+)
+
+// Collection list prize result filters
+const (
+	ResultWin     = "win"
+	ResultLose    = "lose"
+	ResultPending = "pending"
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded or does not
+// belong to the requested sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var sortFields = map[string]string{
+	SortByPurchaseDate: "date",
+	SortByDrawDate:     "prize_date",
+	SortByPrizeAmount:  "prize_amount",
+}
+
+// CollectionQuery filters, sorts and pages a user's collection
+type CollectionQuery struct {
+	NumberPrefix string
+	NumberSuffix string
+	DrawFrom     string // prize_date, inclusive (YYYY-MM-DD)
+	DrawTo       string // prize_date, inclusive (YYYY-MM-DD)
+	Result       string // win, lose, pending
+	PrizeType    string
+	SortBy       string // purchaseDate (default), drawDate, prizeAmount
+	Ascending    bool
+	Limit        int64
+	Cursor       string // nextCursor of the previous page
+}
+
+// pageCursor points just after the last item of a page
+type pageCursor struct {
+	SortBy    string          `json:"s"`
+	Ascending bool            `json:"a"`
+	Value     json.RawMessage `json:"v"`
+	ID        string          `json:"id"`
+}
+
+// FindPage returns one page of a user's collection and the cursor of the next
+// page, which is empty on the last page
+func (r *CollectionRepository) FindPage(ctx context.Context, email string, q CollectionQuery) ([]models.Collection, string, error) {
+	if q.SortBy == "" {
+		q.SortBy = SortByPurchaseDate
+	}
+	field, ok := sortFields[q.SortBy]
+	if !ok {
+		return nil, "", errors.New("invalid sort field")
+	}
+
+	conditions := collectionConditions(email, q)
+	if q.Cursor != "" {
+		after, err := cursorFilter(q, field)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(conditions, after)
+	}
+
+	direction := -1
+	if q.Ascending {
+		direction = 1
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(q.Limit + 1)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"$and": conditions}, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	items := []models.Collection{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, "", err
+	}
+	if int64(len(items)) <= q.Limit {
+		return items, "", nil
+	}
+
+	items = items[:q.Limit]
+	next, err := encodeCursor(q, items[len(items)-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
+}
+
+// collectionConditions builds the filter conditions of a query, without paging
+func collectionConditions(email string, q CollectionQuery) bson.A {
+	conditions := bson.A{bson.M{"email": email}}
+	if q.NumberPrefix != "" {
+		conditions = append(conditions, bson.M{"ticket_number": bson.M{"$regex": "^" + regexp.QuoteMeta(q.NumberPrefix)}})
+	}
+	if q.NumberSuffix != "" {
+		conditions = append(conditions, bson.M{"ticket_number": bson.M{"$regex": regexp.QuoteMeta(q.NumberSuffix) + "$"}})
+	}
+	if q.DrawFrom != "" || q.DrawTo != "" {
+		drawRange := bson.M{}
+		if q.DrawFrom != "" {
+			drawRange["$gte"] = q.DrawFrom
+		}
+		if q.DrawTo != "" {
+			drawRange["$lte"] = q.DrawTo
+		}
+		conditions = append(conditions, bson.M{"prize_date": drawRange})
+	}
+	switch q.Result {
+	case ResultWin:
+		conditions = append(conditions, bson.M{"prize_amount": bson.M{"$gt": 0}})
+	case ResultLose:
+		conditions = append(conditions, bson.M{"prize_type": "lose"})
+	case ResultPending:
+		conditions = append(conditions, bson.M{"prize_type": bson.M{"$in": bson.A{"", nil}}})
+	}
+	if q.PrizeType != "" {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"prize_types": q.PrizeType},
+			bson.M{"prize_type": q.PrizeType},
+		}})
+	}
+	return conditions
+}
+
+// Stream calls fn for every collection matching the query filters, in the
+// query's sort order, without loading them all into memory. Limit and Cursor
+// are ignored
+func (r *CollectionRepository) Stream(ctx context.Context, email string, q CollectionQuery, fn func(models.Collection) error) error {
+	if q.SortBy == "" {
+		q.SortBy = SortByPurchaseDate
+	}
+	field, ok := sortFields[q.SortBy]
+	if !ok {
+		return errors.New("invalid sort field")
+	}
+	direction := -1
+	if q.Ascending {
+		direction = 1
+	}
+	opts := options.Find().SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"$and": collectionConditions(email, q)}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var item models.Collection
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func encodeCursor(q CollectionQuery, last models.Collection) (string, error) {
+	var value interface{}
+	switch q.SortBy {
+	case SortByPurchaseDate:
+		value = last.Date
+	case SortByDrawDate:
+		value = last.PrizeDate
+	case SortByPrizeAmount:
+		value = last.PrizeAmount
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(pageCursor{SortBy: q.SortBy, Ascending: q.Ascending, Value: raw, ID: last.ID.Hex()})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// cursorFilter matches the documents that sort after the cursor position
+func cursorFilter(q CollectionQuery, field string) (bson.M, error) {
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != q.SortBy || c.Ascending != q.Ascending {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var value interface{}
+	switch c.SortBy {
+	case SortByPurchaseDate:
+		var t time.Time
+		err = json.Unmarshal(c.Value, &t)
+		value = t
+	case SortByDrawDate:
+		var s string
+		err = json.Unmarshal(c.Value, &s)
+		value = s
+	case SortByPrizeAmount:
+		var n int
+		err = json.Unmarshal(c.Value, &n)
+		value = n
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	op := "$lt"
+	if q.Ascending {
+		op = "$gt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: id}},
+	}}, nil
+}
@@ -2,12 +2,14 @@ package repositories
 
 import (
 	"context"
+	"log"
 	"time"
 
 	"github.com/user/Lotterich/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CollectionRepository struct {
@@ -15,8 +17,23 @@ type CollectionRepository struct {
 }
 
 func NewCollectionRepository(db *mongo.Database) *CollectionRepository {
+	collection := db.Collection("collection")
+
+	// Indexes backing the paginated list (one per sort order), the ticket number
+	// prefix filter and the per-draw re-check
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "date", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "prize_date", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "prize_amount", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "ticket_number", Value: 1}}},
+		{Keys: bson.D{{Key: "prize_date", Value: 1}}},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
+		panic(err)
+	}
+
 	return &CollectionRepository{
-		collection: db.Collection("collection"),
+		collection: collection,
 	}
 }
 
@@ -31,6 +48,28 @@ func (r *CollectionRepository) Create(item models.Collection) (*models.Collectio
 	return &item, nil
 }
 
+// CreateMany inserts all items or none of them
+// If the insert fails part way, the documents that were written are removed again
+func (r *CollectionRepository) CreateMany(ctx context.Context, items []models.Collection) ([]models.Collection, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	docs := make([]interface{}, len(items))
+	ids := make([]primitive.ObjectID, len(items))
+	for i := range items {
+		items[i].ID = primitive.NewObjectID()
+		ids[i] = items[i].ID
+		docs[i] = items[i]
+	}
+	if _, err := r.collection.InsertMany(ctx, docs); err != nil {
+		if _, delErr := r.collection.DeleteMany(context.Background(), bson.M{"_id": bson.M{"$in": ids}}); delErr != nil {
+			log.Printf("Failed to roll back partial insert of %d collections: %v", len(ids), delErr)
+		}
+		return nil, err
+	}
+	return items, nil
+}
+
 func (r *CollectionRepository) FindByEmail(email string) ([]models.Collection, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -71,16 +110,264 @@ func (r *CollectionRepository) DeleteByEmail(email string) error {
 	return err
 }
 
-// UpdatePrizeFieldsByDate updates prize fields for collections with matching prize date
-func (r *CollectionRepository) UpdatePrizeFieldsByDate(ctx context.Context, date string) error {
-	update := bson.M{
-		"$set": bson.M{
-			"prize_date":   "",
-			"prize_result": "pending",
-			"prize_type":   "",
-			"prize_amount": 0,
-		},
+// UpdateEmail moves all collections of a user to a new email
+func (r *CollectionRepository) UpdateEmail(oldEmail, newEmail string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := r.collection.UpdateMany(ctx, bson.M{"email": oldEmail}, bson.M{"$set": bson.M{"email": newEmail}})
+	return err
+}
+
+// CountByPrizeDate counts collections with matching prize date
+func (r *CollectionRepository) CountByPrizeDate(ctx context.Context, date string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"prize_date": date})
+}
+
+// CountByEmail counts the collections of one email
+func (r *CollectionRepository) CountByEmail(ctx context.Context, email string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"email": email})
+}
+
+// CountByEmails counts the collections of each email
+// Emails without collections are missing from the result
+func (r *CollectionRepository) CountByEmails(ctx context.Context, emails []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(emails))
+	if len(emails) == 0 {
+		return counts, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"email": bson.M{"$in": emails}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$email", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			Email string `bson:"_id"`
+			Count int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.Email] = row.Count
+	}
+	return counts, cursor.Err()
+}
+
+// FindByPrizeDate opens a cursor over collections with matching prize date
+// The caller must close the returned cursor
+func (r *CollectionRepository) FindByPrizeDate(ctx context.Context, date string) (*mongo.Cursor, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	return r.collection.Find(ctx, bson.M{"prize_date": date}, opts)
+}
+
+// FindWinnersByPrizeDate returns the winning collections of a draw, grouped by
+// owner (sorted by email, then ticket number)
+func (r *CollectionRepository) FindWinnersByPrizeDate(ctx context.Context, date string) ([]models.Collection, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "email", Value: 1}, {Key: "ticket_number", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"prize_date": date, "prize_amount": bson.M{"$gt": 0}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	winners := []models.Collection{}
+	if err := cursor.All(ctx, &winners); err != nil {
+		return nil, err
+	}
+	return winners, nil
+}
+
+// UpdatePrizeResults writes the prize fields of many collections in one round trip
+func (r *CollectionRepository) UpdatePrizeResults(ctx context.Context, items []models.Collection) error {
+	if len(items) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(items))
+	for _, item := range items {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": item.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"prize_type":   item.PrizeType,
+				"prize_types":  item.PrizeTypes,
+				"prize_amount": item.PrizeAmount,
+			}}))
 	}
-	_, err := r.collection.UpdateMany(ctx, bson.M{"prize_date": date}, update)
+	_, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
 	return err
 }
+
+// Summary group-by values
+const (
+	SummaryGroupByDraw  = "draw"
+	SummaryGroupByMonth = "month"
+	SummaryGroupByYear  = "year"
+)
+
+// summaryTimezone is used to bucket purchase dates into months and years (Thailand, UTC+7)
+const summaryTimezone = "+07:00"
+
+// SummaryOptions narrows and groups the collection summary
+type SummaryOptions struct {
+	From    *time.Time // purchase date, inclusive
+	To      *time.Time // purchase date, exclusive
+	GroupBy string     // draw, month, year or empty for no groups
+}
+
+// Summarize computes the portfolio totals of a user with a single aggregation
+func (r *CollectionRepository) Summarize(ctx context.Context, email string, opts SummaryOptions, now time.Time) (*models.CollectionSummary, error) {
+	match := bson.M{"email": email}
+	if opts.From != nil || opts.To != nil {
+		dateRange := bson.M{}
+		if opts.From != nil {
+			dateRange["$gte"] = *opts.From
+		}
+		if opts.To != nil {
+			dateRange["$lt"] = *opts.To
+		}
+		match["date"] = dateRange
+	}
+
+	loc := time.FixedZone("ICT", 7*60*60)
+	local := now.In(loc)
+	monthStart := time.Date(local.Year(), local.Month()-5, 1, 0, 0, 0, 0, loc)
+
+	spent := bson.M{"$multiply": bson.A{"$ticket_quantity", "$ticket_amount"}}
+	isWin := bson.M{"$and": bson.A{
+		bson.M{"$gt": bson.A{"$prize_amount", 0}},
+		bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$prize_type", bson.A{"", "lose", nil}}}}},
+	}}
+	prize := bson.M{"$cond": bson.A{isWin, bson.M{"$multiply": bson.A{"$prize_amount", "$ticket_quantity"}}, 0}}
+	sums := bson.M{
+		"total_tickets": bson.M{"$sum": "$ticket_quantity"},
+		"total_spent":   bson.M{"$sum": spent},
+		"total_wins":    bson.M{"$sum": bson.M{"$cond": bson.A{isWin, 1, 0}}},
+		"total_prize":   bson.M{"$sum": prize},
+	}
+
+	totals := bson.M{"_id": nil}
+	for k, v := range sums {
+		totals[k] = v
+	}
+	totals["lose_count"] = bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$prize_type", "lose"}}, 1, 0}}}
+	totals["pending_count"] = bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$prize_type", ""}}, ""}}, 1, 0}}}
+
+	facets := bson.M{
+		"totals": bson.A{bson.M{"$group": totals}},
+		"last_win": bson.A{
+			bson.M{"$match": bson.M{"$expr": isWin}},
+			bson.M{"$sort": bson.D{{Key: "date", Value: -1}}},
+			bson.M{"$limit": 1},
+			bson.M{"$project": bson.M{"ticket_number": 1}},
+		},
+		"monthly": bson.A{
+			bson.M{"$match": bson.M{"date": bson.M{"$gte": monthStart}}},
+			bson.M{"$group": bson.M{
+				"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$date", "timezone": summaryTimezone}},
+				"spent": bson.M{"$sum": spent},
+			}},
+		},
+	}
+
+	var groupKey interface{}
+	switch opts.GroupBy {
+	case SummaryGroupByDraw:
+		groupKey = "$prize_date"
+	case SummaryGroupByMonth:
+		groupKey = bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$date", "timezone": summaryTimezone}}
+	case SummaryGroupByYear:
+		groupKey = bson.M{"$dateToString": bson.M{"format": "%Y", "date": "$date", "timezone": summaryTimezone}}
+	}
+	if groupKey != nil {
+		group := bson.M{"_id": groupKey}
+		for k, v := range sums {
+			group[k] = v
+		}
+		facets["groups"] = bson.A{
+			bson.M{"$group": group},
+			bson.M{"$sort": bson.D{{Key: "_id", Value: 1}}},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$facet", Value: facets}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Totals []struct {
+			TotalTickets int `bson:"total_tickets"`
+			TotalSpent   int `bson:"total_spent"`
+			TotalWins    int `bson:"total_wins"`
+			TotalPrize   int `bson:"total_prize"`
+			LoseCount    int `bson:"lose_count"`
+			PendingCount int `bson:"pending_count"`
+		} `bson:"totals"`
+		LastWin []struct {
+			TicketNumber string `bson:"ticket_number"`
+		} `bson:"last_win"`
+		Monthly []models.MonthlySpending `bson:"monthly"`
+		Groups  []models.SummaryGroup    `bson:"groups"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	summary := &models.CollectionSummary{
+		LastWinningNumber: "-",
+		GroupBy:           opts.GroupBy,
+	}
+	if len(results) == 0 {
+		return summary, nil
+	}
+	res := results[0]
+	if len(res.Totals) > 0 {
+		t := res.Totals[0]
+		summary.TotalTickets = t.TotalTickets
+		summary.TotalSpent = t.TotalSpent
+		summary.TotalWins = t.TotalWins
+		summary.TotalPrize = t.TotalPrize
+		summary.NetProfit = t.TotalPrize - t.TotalSpent
+		summary.WinCount = t.TotalWins
+		summary.LoseCount = t.LoseCount
+		summary.PendingCount = t.PendingCount
+		if decided := summary.WinCount + summary.LoseCount; decided > 0 {
+			summary.WinPercent = float64(summary.WinCount) / float64(decided) * 100
+			summary.LosePercent = float64(summary.LoseCount) / float64(decided) * 100
+		}
+	}
+	if len(res.LastWin) > 0 {
+		summary.LastWinningNumber = res.LastWin[0].TicketNumber
+	}
+	if groupKey != nil {
+		summary.Groups = res.Groups
+		if summary.Groups == nil {
+			summary.Groups = []models.SummaryGroup{}
+		}
+		for i := range summary.Groups {
+			summary.Groups[i].NetProfit = summary.Groups[i].TotalPrize - summary.Groups[i].TotalSpent
+		}
+	}
+
+	// ยอดซื้อ 6 เดือนล่าสุด (รวมเดือนปัจจุบัน) เติม 0 ให้เดือนที่ไม่ได้ซื้อ
+	spentByMonth := make(map[string]int, len(res.Monthly))
+	for _, m := range res.Monthly {
+		spentByMonth[m.Month] = m.Spent
+	}
+	summary.MonthlySpending = make([]models.MonthlySpending, 0, 6)
+	for i := 0; i < 6; i++ {
+		month := monthStart.AddDate(0, i, 0).Format("2006-01")
+		summary.MonthlySpending = append(summary.MonthlySpending, models.MonthlySpending{Month: month, Spent: spentByMonth[month]})
+	}
+	return summary, nil
+}
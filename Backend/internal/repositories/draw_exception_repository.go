@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DrawExceptionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDrawExceptionRepository(db *mongo.Database) *DrawExceptionRepository {
+	collection := db.Collection("draw_exceptions")
+
+	// One exception per regular draw date
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), indexModel); err != nil {
+		log.Printf("Warning: failed to create unique index on draw_exceptions.date: %v", err)
+	}
+
+	return &DrawExceptionRepository{
+		collection: collection,
+	}
+}
+
+func (r *DrawExceptionRepository) Create(ctx context.Context, exception *models.DrawException) error {
+	exception.ID = primitive.NewObjectID()
+	exception.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, exception)
+	return err
+}
+
+func (r *DrawExceptionRepository) GetAll(ctx context.Context) ([]models.DrawException, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	exceptions := []models.DrawException{}
+	if err = cursor.All(ctx, &exceptions); err != nil {
+		return nil, err
+	}
+	return exceptions, nil
+}
+
+// Delete removes an exception and returns it; mongo.ErrNoDocuments if there was none
+func (r *DrawExceptionRepository) Delete(ctx context.Context, id primitive.ObjectID) (*models.DrawException, error) {
+	var exception models.DrawException
+	if err := r.collection.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&exception); err != nil {
+		return nil, err
+	}
+	return &exception, nil
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/user/Lotterich/internal/throttle"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoginAttemptRepository is the MongoDB implementation of throttle.Store
+// One document per key; MongoDB removes it once it has expired
+type LoginAttemptRepository struct {
+	collection *mongo.Collection
+}
+
+type loginAttempt struct {
+	Key         string    `bson:"_id"`
+	Failures    int       `bson:"failures"`
+	LastFailure time.Time `bson:"last_failure"`
+	LockedUntil time.Time `bson:"locked_until"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+var _ throttle.Store = (*LoginAttemptRepository)(nil)
+
+func NewLoginAttemptRepository(db *mongo.Database) *LoginAttemptRepository {
+	collection := db.Collection("login_attempts")
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), indexModel); err != nil {
+		panic(err)
+	}
+
+	return &LoginAttemptRepository{
+		collection: collection,
+	}
+}
+
+func (r *LoginAttemptRepository) Get(ctx context.Context, key string) (throttle.Entry, error) {
+	var doc loginAttempt
+	err := r.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return throttle.Entry{}, nil
+	}
+	if err != nil {
+		return throttle.Entry{}, err
+	}
+	return doc.entry(), nil
+}
+
+// Increment uses an update pipeline so that the window check and the increment
+// happen in one atomic write
+func (r *LoginAttemptRepository) Increment(ctx context.Context, key string, now time.Time, window time.Duration) (throttle.Entry, error) {
+	stale := bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$last_failure", time.Time{}}}, now.Add(-window)}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures": bson.M{"$cond": bson.A{
+				stale,
+				1,
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+			}},
+			"last_failure": now,
+			"locked_until": bson.M{"$ifNull": bson.A{"$locked_until", time.Time{}}},
+			"expires_at":   bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$expires_at", time.Time{}}}, now.Add(window)}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc loginAttempt
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&doc); err != nil {
+		return throttle.Entry{}, err
+	}
+	return doc.entry(), nil
+}
+
+func (r *LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$set": bson.M{"locked_until": until},
+			"$max": bson.M{"expires_at": until},
+		},
+	)
+	return err
+}
+
+func (r *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+func (d loginAttempt) entry() throttle.Entry {
+	return throttle.Entry{
+		Failures:    d.Failures,
+		LastFailure: d.LastFailure,
+		LockedUntil: d.LockedUntil,
+	}
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotifyJobRepository เก็บงานส่งการแจ้งเตือนถึงผู้ที่สมัครรับ (collection notify_jobs)
+type NotifyJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotifyJobRepository(db *mongo.Database) *NotifyJobRepository {
+	collection := db.Collection("notify_jobs")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
+		panic(err)
+	}
+
+	return &NotifyJobRepository{
+		collection: collection,
+	}
+}
+
+// Create บันทึกงานใหม่ คืน false ถ้ามีงานที่ key เดียวกันอยู่แล้ว
+func (r *NotifyJobRepository) Create(ctx context.Context, job *models.NotifyJob) (bool, error) {
+	job.ID = primitive.NewObjectID()
+	job.CreatedAt = time.Now()
+	if _, err := r.collection.InsertOne(ctx, job); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Save เขียนสถานะและความคืบหน้าล่าสุดของงานทับข้อมูลเดิม
+func (r *NotifyJobRepository) Save(ctx context.Context, job *models.NotifyJob) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	return err
+}
+
+// FindUnfinished คืนงานที่ยังไม่เสร็จ (รอทำหรือค้างอยู่ตอนเซิร์ฟเวอร์หยุด) เรียงตามเวลาที่สร้าง
+func (r *NotifyJobRepository) FindUnfinished(ctx context.Context) ([]models.NotifyJob, error) {
+	filter := bson.M{"status": bson.M{"$in": []string{models.NotifyJobQueued, models.NotifyJobRunning}}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []models.NotifyJob{}
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
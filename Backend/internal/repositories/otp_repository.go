@@ -7,6 +7,7 @@ import (
 
 	"github.com/user/Lotterich/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -18,20 +19,23 @@ type OTPRepository struct {
 func NewOTPRepository(db *mongo.Database) *OTPRepository {
 	collection := db.Collection("otp")
 
-	// Drop existing TTL index if exists (ignore error if ns not found)
+	// Drop the TTL index of the old plaintext OTPs (ignore error if ns/index not found)
 	_, err := collection.Indexes().DropOne(context.Background(), "date_otp_1")
-	if err != nil && !strings.Contains(err.Error(), "ns does not exist") && !strings.Contains(err.Error(), "NamespaceNotFound") {
+	if err != nil && !strings.Contains(err.Error(), "ns does not exist") && !strings.Contains(err.Error(), "NamespaceNotFound") && !strings.Contains(err.Error(), "index not found") {
 		panic(err)
 	}
-
-	// Create new TTL index on date_otp field
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "date_otp", Value: 1}},
-		Options: options.Index().SetExpireAfterSeconds(180), // 3 minutes = 180 seconds
+	// Old OTPs were stored in plaintext; remove them
+	if _, err := collection.DeleteMany(context.Background(), bson.M{"code_hash": bson.M{"$exists": false}}); err != nil {
+		panic(err)
 	}
 
-	_, err = collection.Indexes().CreateOne(context.Background(), indexModel)
-	if err != nil {
+	indexes := []mongo.IndexModel{
+		// One live code per email and purpose
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "purpose", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Expired codes are removed by MongoDB
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
 		panic(err)
 	}
 
@@ -40,34 +44,80 @@ func NewOTPRepository(db *mongo.Database) *OTPRepository {
 	}
 }
 
-func (r *OTPRepository) CreateOrUpdate(email, otp string) error {
+// Issue stores a new code for email and purpose, replacing the previous one
+func (r *OTPRepository) Issue(email, purpose, codeHash string, ttl time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	filter := bson.M{"email": email}
+	now := time.Now()
+	filter := bson.M{"email": email, "purpose": purpose}
 	update := bson.M{
 		"$set": bson.M{
-			"number_otp": otp,
-			"date_otp":   time.Now(),
+			"code_hash":  codeHash,
+			"created_at": now,
+			"expires_at": now.Add(ttl),
+			"attempts":   0,
 		},
 	}
 	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
 	return err
 }
 
-func (r *OTPRepository) FindByEmail(email string) (*models.OTP, error) {
+func (r *OTPRepository) Find(email, purpose string) (*models.OTP, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	var otp models.OTP
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&otp)
+	err := r.collection.FindOne(ctx, bson.M{"email": email, "purpose": purpose}).Decode(&otp)
 	if err != nil {
 		return nil, err
 	}
 	return &otp, nil
 }
 
+// FindByCodeHash finds an unexpired code of a purpose by its hash, for codes
+// that arrive without an email (e.g. a Telegram deep-link token)
+func (r *OTPRepository) FindByCodeHash(purpose, codeHash string) (*models.OTP, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var otp models.OTP
+	filter := bson.M{"purpose": purpose, "code_hash": codeHash, "expires_at": bson.M{"$gt": time.Now()}}
+	if err := r.collection.FindOne(ctx, filter).Decode(&otp); err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// RecordFailedAttempt counts a wrong code and returns the number of wrong tries so far
+func (r *OTPRepository) RecordFailedAttempt(id primitive.ObjectID) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var otp models.OTP
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&otp)
+	if err != nil {
+		return 0, err
+	}
+	return otp.Attempts, nil
+}
+
+// Delete removes a code. It reports false when the code was already gone,
+// which makes using a code a single-use operation
+func (r *OTPRepository) Delete(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount == 1, nil
+}
+
+// DeleteByEmail removes every code of an email, whatever the purpose
 func (r *OTPRepository) DeleteByEmail(email string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_, err := r.collection.DeleteOne(ctx, bson.M{"email": email})
+	_, err := r.collection.DeleteMany(ctx, bson.M{"email": email})
 	return err
 }
@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sentOutboxRetention คือระยะเวลาที่เก็บข้อความที่ส่งสำเร็จไว้ก่อน MongoDB ลบทิ้ง
+const sentOutboxRetention = 30 * 24 * time.Hour
+
+// deadOutboxRetention คือระยะเวลาที่เก็บข้อความ dead และ expired ไว้ก่อนลบทิ้ง
+// (ข้อความ dead ที่ถูกสั่งส่งใหม่จะไม่ถูกลบ)
+const deadOutboxRetention = 30 * 24 * time.Hour
+
+// outboxBodyProjection ตัดเนื้อความออกจากผลลัพธ์ที่ส่งให้ admin
+var outboxBodyProjection = bson.M{"text": 0, "html": 0}
+
+// OutboxRepository เก็บการแจ้งเตือนที่รอส่ง (collection notification_outbox)
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository(db *mongo.Database) *OutboxRepository {
+	collection := db.Collection("notification_outbox")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "idempotency_key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "channel", Value: 1}, {Key: "created_at", Value: -1}}},
+		// ลบข้อความที่ส่งแล้วอัตโนมัติ (TTL ใช้กับเอกสารที่มี sent_at เท่านั้น)
+		{
+			Keys:    bson.D{{Key: "sent_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(sentOutboxRetention.Seconds())),
+		},
+		// ลบข้อความ dead และ expired เมื่อถึง discard_at
+		{
+			Keys:    bson.D{{Key: "discard_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
+		panic(err)
+	}
+
+	// error ของการส่งรุ่นก่อนอาจมี URL ของช่องทางติดมา (ซึ่งมี token ของบอท Telegram) ลบทิ้ง
+	if _, err := collection.UpdateMany(context.Background(),
+		bson.M{"last_error": bson.M{"$regex": "https?://"}},
+		bson.M{"$set": bson.M{"last_error": "request failed (details removed)"}},
+	); err != nil {
+		panic(err)
+	}
+
+	return &OutboxRepository{
+		collection: collection,
+	}
+}
+
+// Enqueue เพิ่มข้อความที่จะส่งตั้งแต่ msg.NextAttemptAt (ว่าง = ส่งทันที)
+// คืน false ถ้ามีข้อความที่ idempotency key เดียวกันอยู่แล้ว
+func (r *OutboxRepository) Enqueue(ctx context.Context, msg *models.OutboxMessage) (bool, error) {
+	now := time.Now()
+	msg.ID = primitive.NewObjectID()
+	msg.Status = models.OutboxPending
+	if msg.NextAttemptAt.Before(now) {
+		msg.NextAttemptAt = now
+	}
+	msg.CreatedAt = now
+	msg.UpdatedAt = now
+	if _, err := r.collection.InsertOne(ctx, msg); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ClaimDue จองข้อความที่ถึงเวลาส่งหนึ่งข้อความไว้ lease นาน (เก่าสุดก่อน)
+// ข้อความที่ถูกจองไว้แต่ไม่ได้ส่งจนหมด lease (เช่นเซิร์ฟเวอร์ล่ม) จะถูกจองใหม่ได้
+// คืน mongo.ErrNoDocuments ถ้าไม่มีข้อความที่ถึงเวลา
+func (r *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.OutboxMessage, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.OutboxPending, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"status": models.OutboxSending, "locked_until": bson.M{"$lte": now}},
+	}}
+	update := bson.M{"$set": bson.M{
+		"status":       models.OutboxSending,
+		"locked_until": now.Add(lease),
+		"updated_at":   now,
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var msg models.OutboxMessage
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// MarkSent บันทึกว่าส่งสำเร็จและลบเนื้อความทิ้ง
+func (r *OutboxRepository) MarkSent(ctx context.Context, id primitive.ObjectID, attempts int) error {
+	now := time.Now()
+	return r.finish(ctx, id, bson.M{
+		"status":     models.OutboxSent,
+		"attempts":   attempts,
+		"sent_at":    now,
+		"updated_at": now,
+	}, true)
+}
+
+// MarkExpired บันทึกว่าข้อความหมดอายุก่อนส่งได้และลบเนื้อความทิ้ง
+func (r *OutboxRepository) MarkExpired(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	return r.finish(ctx, id, bson.M{
+		"status":     models.OutboxExpired,
+		"updated_at": now,
+		"discard_at": now.Add(deadOutboxRetention),
+	}, true)
+}
+
+// MarkRetry บันทึกการส่งที่ล้มเหลวและนัดส่งใหม่ที่เวลา next
+func (r *OutboxRepository) MarkRetry(ctx context.Context, id primitive.ObjectID, attempts int, next time.Time, lastError string) error {
+	return r.finish(ctx, id, bson.M{
+		"status":          models.OutboxPending,
+		"attempts":        attempts,
+		"next_attempt_at": next,
+		"last_error":      lastError,
+		"updated_at":      time.Now(),
+	}, false)
+}
+
+// MarkDead บันทึกว่าส่งไม่สำเร็จจนเลิกลองแล้ว เนื้อความถูกเก็บไว้เพื่อส่งใหม่
+// ยกเว้นข้อความที่มีวันหมดอายุ (dropBody เช่นอีเมล OTP) ซึ่งส่งใหม่ไม่ได้และเนื้อความถูกลบทิ้ง
+func (r *OutboxRepository) MarkDead(ctx context.Context, id primitive.ObjectID, attempts int, lastError string, dropBody bool) error {
+	now := time.Now()
+	return r.finish(ctx, id, bson.M{
+		"status":     models.OutboxDead,
+		"attempts":   attempts,
+		"last_error": lastError,
+		"updated_at": now,
+		"discard_at": now.Add(deadOutboxRetention),
+	}, dropBody)
+}
+
+func (r *OutboxRepository) finish(ctx context.Context, id primitive.ObjectID, set bson.M, dropBody bool) error {
+	unset := bson.M{"locked_until": ""}
+	if dropBody {
+		unset["text"] = ""
+		unset["html"] = ""
+	}
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{"$set": set, "$unset": unset})
+	return err
+}
+
+// Replay ส่งข้อความที่ dead ใหม่ตั้งแต่ต้น คืน mongo.ErrNoDocuments ถ้าไม่มีข้อความ dead
+// ที่ id นี้ ข้อความที่มีวันหมดอายุ (expires_at) ส่งใหม่ไม่ได้
+func (r *OutboxRepository) Replay(ctx context.Context, id primitive.ObjectID) (*models.OutboxMessage, error) {
+	now := time.Now()
+	filter := bson.M{"_id": id, "status": models.OutboxDead, "expires_at": bson.M{"$exists": false}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":          models.OutboxPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		},
+		"$unset": bson.M{"discard_at": ""},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(outboxBodyProjection)
+
+	var msg models.OutboxMessage
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetByID คืนข้อความตาม id โดยไม่มีเนื้อความ
+func (r *OutboxRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	opts := options.FindOne().SetProjection(outboxBodyProjection)
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Search คืนข้อความหนึ่งหน้า (ไม่มีเนื้อความ) ใหม่สุดก่อน พร้อมจำนวนทั้งหมดที่ตรงเงื่อนไข
+func (r *OutboxRepository) Search(ctx context.Context, search models.OutboxSearch) ([]models.OutboxMessage, int64, error) {
+	filter := bson.M{}
+	if search.Status != "" {
+		filter["status"] = search.Status
+	}
+	if search.Channel != "" {
+		filter["channel"] = search.Channel
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((search.Page - 1) * search.Limit)).
+		SetLimit(int64(search.Limit)).
+		SetProjection(outboxBodyProjection)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []models.OutboxMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
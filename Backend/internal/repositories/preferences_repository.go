@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PreferencesRepository เก็บการตั้งค่าการแจ้งเตือนของผู้ใช้ (หนึ่งเอกสารต่อผู้ใช้)
+type PreferencesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPreferencesRepository(db *mongo.Database) *PreferencesRepository {
+	collection := db.Collection("user_preferences")
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "events.new_draw", Value: 1}, {Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "events.weekly_digest", Value: 1}, {Key: "user_id", Value: 1}}},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
+		panic(err)
+	}
+
+	r := &PreferencesRepository{
+		collection: collection,
+	}
+	if err := r.migrateWinNotifyChannel(context.Background(), db.Collection("users")); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// migrateWinNotifyChannel ย้ายช่องทางแจ้งรางวัลเดิม (users.win_notify_channel)
+// มาเป็นการตั้งค่า: telegram = ส่งทาง Telegram แทนอีเมล, none = ไม่แจ้งเมื่อถูกรางวัล
+// ผู้ใช้ที่มีการตั้งค่าอยู่แล้วไม่ถูกเปลี่ยน แล้วลบฟิลด์เดิมทิ้ง
+func (r *PreferencesRepository) migrateWinNotifyChannel(ctx context.Context, users *mongo.Collection) error {
+	filter := bson.M{"win_notify_channel": bson.M{"$exists": true}}
+	cursor, err := users.Find(ctx, filter, options.Find().SetProjection(bson.M{"win_notify_channel": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var user struct {
+			ID      primitive.ObjectID `bson:"_id"`
+			Channel string             `bson:"win_notify_channel"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		prefs := models.DefaultPreferences(user.ID.Hex())
+		switch user.Channel {
+		case models.ChannelTelegram:
+			prefs.Channels = models.NotificationChannels{Telegram: true}
+		case "none":
+			prefs.Events.TicketWon = false
+		}
+		prefs.UpdatedAt = time.Now()
+		_, err := r.collection.UpdateOne(ctx,
+			bson.M{"user_id": prefs.UserID},
+			bson.M{"$setOnInsert": prefs},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+		migrated++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if migrated == 0 {
+		return nil
+	}
+
+	if _, err := users.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"win_notify_channel": ""}}); err != nil {
+		return err
+	}
+	log.Printf("Moved the win notification channel of %d users to their preferences", migrated)
+	return nil
+}
+
+// Get returns the preferences of a user, or the defaults if they never saved any
+func (r *PreferencesRepository) Get(ctx context.Context, userID string) (*models.Preferences, error) {
+	var prefs models.Preferences
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&prefs)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.DefaultPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// Save creates or replaces the preferences of prefs.UserID
+func (r *PreferencesRepository) Save(ctx context.Context, prefs *models.Preferences) error {
+	prefs.UpdatedAt = time.Now()
+	// _id ของเอกสารเดิมเปลี่ยนไม่ได้ จึงไม่ส่งไปกับเอกสารใหม่
+	doc := *prefs
+	doc.ID = primitive.NilObjectID
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"user_id": prefs.UserID}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+// FindUserIDsByEvent returns up to limit users who turned event on, in user_id
+// order starting after the user afterID (empty = from the start), so a long
+// list can be read in pages. Only opt-in events (off by default) can be found this way
+func (r *PreferencesRepository) FindUserIDsByEvent(ctx context.Context, event, afterID string, limit int64) ([]string, error) {
+	field := map[string]string{
+		models.EventNewDraw:      "events.new_draw",
+		models.EventWeeklyDigest: "events.weekly_digest",
+	}[event]
+	if field == "" {
+		return nil, errors.New("event is on by default and can't be searched: " + event)
+	}
+
+	filter := bson.M{field: true}
+	if afterID != "" {
+		filter["user_id"] = bson.M{"$gt": afterID}
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"user_id": 1}).
+		SetSort(bson.D{{Key: "user_id", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var userIDs []string
+	for cursor.Next(ctx) {
+		var prefs models.Preferences
+		if err := cursor.Decode(&prefs); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, prefs.UserID)
+	}
+	return userIDs, cursor.Err()
+}
+
+// Delete removes the preferences of a deleted account
+func (r *PreferencesRepository) Delete(ctx context.Context, userID string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID})
+	return err
+}
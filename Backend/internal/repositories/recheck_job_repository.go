@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RecheckJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRecheckJobRepository(db *mongo.Database) *RecheckJobRepository {
+	return &RecheckJobRepository{
+		collection: db.Collection("recheck_jobs"),
+	}
+}
+
+func (r *RecheckJobRepository) Create(ctx context.Context, job *models.RecheckJob) error {
+	job.ID = primitive.NewObjectID()
+	job.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, job)
+	return err
+}
+
+// Save เขียนสถานะและความคืบหน้าล่าสุดของงานทับข้อมูลเดิม
+func (r *RecheckJobRepository) Save(ctx context.Context, job *models.RecheckJob) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	return err
+}
+
+func (r *RecheckJobRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.RecheckJob, error) {
+	var job models.RecheckJob
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindRecent คืนงานล่าสุดไม่เกิน limit งาน กรองตามงวดถ้าระบุ prizeDate
+func (r *RecheckJobRepository) FindRecent(ctx context.Context, prizeDate string, limit int64) ([]models.RecheckJob, error) {
+	filter := bson.M{}
+	if prizeDate != "" {
+		filter["prize_date"] = prizeDate
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []models.RecheckJob{}
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
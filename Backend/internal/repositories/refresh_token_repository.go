@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RefreshTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRefreshTokenRepository(db *mongo.Database) *RefreshTokenRepository {
+	collection := db.Collection("refresh_tokens")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+		// Expired tokens are removed by MongoDB
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
+		panic(err)
+	}
+
+	return &RefreshTokenRepository{
+		collection: collection,
+	}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke revokes a single token. It reports false when the token was already
+// revoked, so two concurrent refreshes cannot both rotate the same token
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RevokeFamily revokes every token of one login
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"family_id": familyID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	return err
+}
+
+// RevokeFamilies revokes every token of several logins at once
+func (r *RefreshTokenRepository) RevokeFamilies(ctx context.Context, familyIDs []primitive.ObjectID) error {
+	if len(familyIDs) == 0 {
+		return nil
+	}
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"family_id": bson.M{"$in": familyIDs}, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	return err
+}
+
+// RevokeAllForUser revokes every token of a user, e.g. after a password change
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	return err
+}
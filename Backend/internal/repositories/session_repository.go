@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sessionTouchInterval limits how often last_seen_at is written for a session
+const sessionTouchInterval = time.Minute
+
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(db *mongo.Database) *SessionRepository {
+	collection := db.Collection("sessions")
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_seen_at", Value: -1}}},
+		// Expired sessions are removed by MongoDB
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
+		panic(err)
+	}
+
+	return &SessionRepository{
+		collection: collection,
+	}
+}
+
+// Create stores a new session. The caller picks the ID so that it can be shared
+// with the refresh token family of the login
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var session models.Session
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindActiveByUser returns the sessions of a user that are neither revoked nor expired,
+// most recently used first
+func (r *SessionRepository) FindActiveByUser(ctx context.Context, userID string) ([]models.Session, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Touch records activity on a session. Writes are skipped when the session was
+// seen less than sessionTouchInterval ago so that every request doesn't cost a write
+func (r *SessionRepository) Touch(ctx context.Context, session *models.Session, ip string) error {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval && session.IP == ip {
+		return nil
+	}
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": session.ID},
+		bson.M{"$set": bson.M{"last_seen_at": now, "ip": ip}},
+	)
+	return err
+}
+
+// Extend moves the expiry of an active session, e.g. after its refresh token was rotated
+func (r *SessionRepository) Extend(ctx context.Context, id primitive.ObjectID, expiresAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"expires_at": expiresAt, "last_seen_at": time.Now()}},
+	)
+	return err
+}
+
+// Revoke revokes one session of a user. It reports false when the session does
+// not exist, belongs to someone else or was already revoked
+func (r *SessionRepository) Revoke(ctx context.Context, userID string, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RevokeOthers revokes every session of a user except keepID and returns the
+// IDs of the sessions it revoked
+func (r *SessionRepository) RevokeOthers(ctx context.Context, userID string, keepID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"_id":        bson.M{"$ne": keepID},
+		"revoked_at": bson.M{"$exists": false},
+	}
+	return r.revokeMany(ctx, filter)
+}
+
+// RevokeAllForUser revokes every session of a user, e.g. after a password change
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	_, err := r.revokeMany(ctx, bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}})
+	return err
+}
+
+func (r *SessionRepository) revokeMany(ctx context.Context, filter bson.M) ([]primitive.ObjectID, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -3,11 +3,13 @@ package repositories
 import (
 	"context"
 	"errors"
+	"log"
 
 	"github.com/user/Lotterich/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type StatisticsRepository struct {
@@ -15,8 +17,20 @@ type StatisticsRepository struct {
 }
 
 func NewStatisticsRepository(db *mongo.Database) *StatisticsRepository {
+	collection := db.Collection("statistics")
+
+	// One result per draw date. Existing duplicate draws make this fail, so only
+	// warn instead of refusing to start; they have to be cleaned up by hand
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), indexModel); err != nil {
+		log.Printf("Warning: failed to create unique index on statistics.date: %v", err)
+	}
+
 	return &StatisticsRepository{
-		collection: db.Collection("statistics"),
+		collection: collection,
 	}
 }
 
@@ -56,6 +70,10 @@ func (r *StatisticsRepository) Update(ctx context.Context, id primitive.ObjectID
 	update := map[string]interface{}{
 		"date":       stat.Date,
 		"prize1":     stat.Prize1,
+		"prize2":     stat.Prize2,
+		"prize3":     stat.Prize3,
+		"prize4":     stat.Prize4,
+		"prize5":     stat.Prize5,
 		"first3_one": stat.First3One,
 		"first3_two": stat.First3Two,
 		"last3_one":  stat.Last3One,
@@ -66,6 +84,13 @@ func (r *StatisticsRepository) Update(ctx context.Context, id primitive.ObjectID
 	return err
 }
 
+// Restore เขียนผลรางวัลทับทั้งเอกสารตาม stat.ID และสร้างใหม่ถ้าถูกลบไปแล้ว (ใช้ตอนย้อนเวอร์ชัน)
+func (r *StatisticsRepository) Restore(ctx context.Context, stat *models.Statistics) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": stat.ID}, stat, opts)
+	return err
+}
+
 func (r *StatisticsRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Statistics, error) {
 	var stat models.Statistics
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&stat)
@@ -74,3 +99,61 @@ func (r *StatisticsRepository) GetByID(ctx context.Context, id primitive.ObjectI
 	}
 	return &stat, nil
 }
+
+func (r *StatisticsRepository) GetByDate(ctx context.Context, date string) (*models.Statistics, error) {
+	var stat models.Statistics
+	err := r.collection.FindOne(ctx, bson.M{"date": date}).Decode(&stat)
+	if err != nil {
+		return nil, err
+	}
+	return &stat, nil
+}
+
+// GetLatest returns the n most recent draws, newest first
+func (r *StatisticsRepository) GetLatest(ctx context.Context, n int64) ([]models.Statistics, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}}).SetLimit(n)
+	return r.find(ctx, bson.M{}, opts)
+}
+
+// GetByDateRange returns the draws between from and to (inclusive, YYYY-MM-DD), newest first
+// An empty bound is left open
+func (r *StatisticsRepository) GetByDateRange(ctx context.Context, from, to string) ([]models.Statistics, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}})
+	return r.find(ctx, dateRangeFilter(from, to), opts)
+}
+
+// FindByDateRange is GetByDateRange as a cursor, for exports that shouldn't
+// load every draw into memory. The caller closes the cursor
+func (r *StatisticsRepository) FindByDateRange(ctx context.Context, from, to string) (*mongo.Cursor, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}})
+	return r.collection.Find(ctx, dateRangeFilter(from, to), opts)
+}
+
+func dateRangeFilter(from, to string) bson.M {
+	dateRange := bson.M{}
+	if from != "" {
+		dateRange["$gte"] = from
+	}
+	if to != "" {
+		dateRange["$lte"] = to
+	}
+	filter := bson.M{}
+	if len(dateRange) > 0 {
+		filter["date"] = dateRange
+	}
+	return filter
+}
+
+func (r *StatisticsRepository) find(ctx context.Context, filter interface{}, opts *options.FindOptions) ([]models.Statistics, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	stats := []models.Statistics{}
+	if err = cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/Lotterich/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StatisticsRevisionRepository เก็บทุกเวอร์ชันของผลรางวัล เพิ่มได้อย่างเดียว ไม่มีการแก้ไขหรือลบ
+type StatisticsRevisionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewStatisticsRevisionRepository(db *mongo.Database) *StatisticsRevisionRepository {
+	collection := db.Collection("statistics_revisions")
+
+	// เลขเวอร์ชันไม่ซ้ำกันในผลรางวัลเดียวกัน
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "statistics_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), indexModel); err != nil {
+		panic(err)
+	}
+
+	return &StatisticsRevisionRepository{
+		collection: collection,
+	}
+}
+
+// Record บันทึกเวอร์ชันถัดไปของผลรางวัล rev.StatisticsID และกำหนด rev.Version ให้
+// ถ้ามีการบันทึกพร้อมกันจนเลขเวอร์ชันชนกันจะลองใหม่
+func (r *StatisticsRevisionRepository) Record(ctx context.Context, rev *models.StatisticsRevision) error {
+	rev.CreatedAt = time.Now()
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		var latest int
+		if latest, err = r.latestVersion(ctx, rev.StatisticsID); err != nil {
+			return err
+		}
+		rev.ID = primitive.NewObjectID()
+		rev.Version = latest + 1
+		if _, err = r.collection.InsertOne(ctx, rev); !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// HasAny บอกว่าผลรางวัลนี้มีประวัติแล้วหรือยัง
+func (r *StatisticsRevisionRepository) HasAny(ctx context.Context, statisticsID primitive.ObjectID) (bool, error) {
+	latest, err := r.latestVersion(ctx, statisticsID)
+	return latest > 0, err
+}
+
+// FindByStatistics คืนทุกเวอร์ชันของผลรางวัล เวอร์ชันล่าสุดก่อน
+func (r *StatisticsRevisionRepository) FindByStatistics(ctx context.Context, statisticsID primitive.ObjectID) ([]models.StatisticsRevision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"statistics_id": statisticsID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []models.StatisticsRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// Get คืนเวอร์ชันเดียว หรือ mongo.ErrNoDocuments ถ้าไม่มี
+func (r *StatisticsRevisionRepository) Get(ctx context.Context, statisticsID primitive.ObjectID, version int) (*models.StatisticsRevision, error) {
+	var rev models.StatisticsRevision
+	err := r.collection.FindOne(ctx, bson.M{"statistics_id": statisticsID, "version": version}).Decode(&rev)
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func (r *StatisticsRevisionRepository) latestVersion(ctx context.Context, statisticsID primitive.ObjectID) (int, error) {
+	var rev models.StatisticsRevision
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1})
+	err := r.collection.FindOne(ctx, bson.M{"statistics_id": statisticsID}, opts).Decode(&rev)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return rev.Version, nil
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"log"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/user/Lotterich/internal/models"
 )
@@ -22,6 +24,18 @@ type UserRepository struct {
 func NewUserRepository(db *mongo.Database) *UserRepository {
 	collection := db.Collection("users")
 	log.Printf("Initialized UserRepository with collection: %s", collection.Name())
+
+	// Accounts created before email verification existed count as verified
+	result, err := collection.UpdateMany(context.Background(),
+		bson.M{"email_verified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"email_verified": true}},
+	)
+	if err != nil {
+		panic(err)
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("Marked %d existing users as email verified", result.ModifiedCount)
+	}
 	return &UserRepository{
 		collection: collection,
 	}
@@ -46,7 +60,7 @@ func (r *UserRepository) Create(user models.User) (*models.User, error) {
 	}
 
 	// Set role
-	user.Role = "user"
+	user.Role = models.RoleUser
 
 	// Set timestamps
 	now := time.Now()
@@ -164,8 +178,9 @@ func (r *UserRepository) UpdatePassword(userID string, newPasswordHash string) e
 
 	update := bson.M{
 		"$set": bson.M{
-			"password_hash": newPasswordHash,
-			"updated_at":    time.Now(),
+			"password_hash":           newPasswordHash,
+			"password_reset_required": false,
+			"updated_at":              time.Now(),
 		},
 	}
 
@@ -184,6 +199,327 @@ func (r *UserRepository) UpdatePassword(userID string, newPasswordHash string) e
 	return nil
 }
 
+// UpdateEmail changes the email of a user
+func (r *UserRepository) UpdateEmail(userID string, newEmail string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log.Printf("Attempting to update email for user ID: %s", userID)
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Printf("Invalid ObjectID format: %v", err)
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"email":          newEmail,
+			"email_verified": false,
+			"updated_at":     time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		log.Printf("Error updating email: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		log.Printf("No user found to update email with ID: %s", userID)
+		return errors.New("ไม่พบผู้ใช้งานนี้")
+	}
+
+	log.Printf("Successfully updated email for user ID: %s", userID)
+	return nil
+}
+
+// RestoreEmail puts back the email of a user after a failed email change
+func (r *UserRepository) RestoreEmail(userID string, email string, verified bool) error {
+	return r.updateByID(userID, bson.M{"$set": bson.M{
+		"email":          email,
+		"email_verified": verified,
+		"updated_at":     time.Now(),
+	}})
+}
+
+// MarkEmailVerified marks the email of a user as verified
+func (r *UserRepository) MarkEmailVerified(email string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"email": email},
+		bson.M{"$set": bson.M{"email_verified": true, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Error verifying email: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("ไม่พบผู้ใช้งานนี้")
+	}
+
+	log.Printf("Email verified: %s", email)
+	return nil
+}
+
+// SetPendingTOTP stores a TOTP secret that is waiting to be confirmed
+func (r *UserRepository) SetPendingTOTP(userID string, secret string) error {
+	return r.updateByID(userID, bson.M{"$set": bson.M{"totp_pending_secret": secret, "updated_at": time.Now()}})
+}
+
+// EnableTwoFactor turns on two-factor login with a confirmed secret
+func (r *UserRepository) EnableTwoFactor(userID string, secret string, step int64, recoveryCodeHashes []string) error {
+	return r.updateByID(userID, bson.M{
+		"$set": bson.M{
+			"two_factor_enabled": true,
+			"totp_secret":        secret,
+			"totp_last_step":     step,
+			"recovery_codes":     recoveryCodeHashes,
+			"updated_at":         time.Now(),
+		},
+		"$unset": bson.M{"totp_pending_secret": ""},
+	})
+}
+
+// DisableTwoFactor turns off two-factor login and forgets the secret and recovery codes
+func (r *UserRepository) DisableTwoFactor(userID string) error {
+	return r.updateByID(userID, bson.M{
+		"$set":   bson.M{"two_factor_enabled": false, "updated_at": time.Now()},
+		"$unset": bson.M{"totp_secret": "", "totp_pending_secret": "", "totp_last_step": "", "recovery_codes": ""},
+	})
+}
+
+// SetTelegramChat links a Telegram chat to a user. A chat belongs to one
+// account only, so it is unlinked from any other account first
+func (r *UserRepository) SetTelegramChat(userID string, chatID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	if _, err := r.collection.UpdateMany(ctx,
+		bson.M{"telegram_chat_id": chatID, "_id": bson.M{"$ne": objID}},
+		bson.M{"$unset": bson.M{"telegram_chat_id": ""}, "$set": bson.M{"updated_at": time.Now()}},
+	); err != nil {
+		return err
+	}
+	return r.updateByID(userID, bson.M{"$set": bson.M{"telegram_chat_id": chatID, "updated_at": time.Now()}})
+}
+
+// FindByTelegramChat returns the user a Telegram chat is linked to
+func (r *UserRepository) FindByTelegramChat(chatID string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := r.collection.FindOne(ctx, bson.M{"telegram_chat_id": chatID}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ClearTelegramChat unlinks the Telegram chat of a user. Personal messages
+// then go to the user's other channels
+func (r *UserRepository) ClearTelegramChat(userID string) error {
+	return r.updateByID(userID, bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"telegram_chat_id": ""},
+	})
+}
+
+// SetLineUser links a LINE account to a user. Like a Telegram chat, a LINE
+// account belongs to one account only
+func (r *UserRepository) SetLineUser(userID string, lineUserID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	if _, err := r.collection.UpdateMany(ctx,
+		bson.M{"line_user_id": lineUserID, "_id": bson.M{"$ne": objID}},
+		bson.M{"$unset": bson.M{"line_user_id": ""}, "$set": bson.M{"updated_at": time.Now()}},
+	); err != nil {
+		return err
+	}
+	return r.updateByID(userID, bson.M{"$set": bson.M{"line_user_id": lineUserID, "updated_at": time.Now()}})
+}
+
+// FindByLineUser returns the user a LINE account is linked to
+func (r *UserRepository) FindByLineUser(lineUserID string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := r.collection.FindOne(ctx, bson.M{"line_user_id": lineUserID}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ClearLineUser unlinks the LINE account of a user
+func (r *UserRepository) ClearLineUser(userID string) error {
+	return r.updateByID(userID, bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"line_user_id": ""},
+	})
+}
+
+// SetRecoveryCodes replaces the recovery codes of a user
+func (r *UserRepository) SetRecoveryCodes(userID string, recoveryCodeHashes []string) error {
+	return r.updateByID(userID, bson.M{"$set": bson.M{"recovery_codes": recoveryCodeHashes, "updated_at": time.Now()}})
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code
+// It reports false when that step (or a later one) was already used, so a code works once
+func (r *UserRepository) UseTOTPStep(userID string, step int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objID, "$or": bson.A{
+			bson.M{"totp_last_step": bson.M{"$lt": step}},
+			bson.M{"totp_last_step": bson.M{"$exists": false}},
+		}},
+		bson.M{"$set": bson.M{"totp_last_step": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// UseRecoveryCode removes a recovery code. It reports false when the user doesn't have that code
+func (r *UserRepository) UseRecoveryCode(userID string, codeHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objID, "recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"recovery_codes": codeHash}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// updateByID applies an update to one user
+func (r *UserRepository) updateByID(userID string, update bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		log.Printf("Invalid ObjectID format: %v", err)
+		return err
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		log.Printf("Error updating user: %v", err)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("ไม่พบผู้ใช้งานนี้")
+	}
+	return nil
+}
+
+// Search returns one page of users matching the search and the total number of matches
+func (r *UserRepository) Search(search models.UserSearch) ([]models.User, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if search.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(search.Search), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"email": pattern}}
+	}
+	if search.Role != "" {
+		filter["role"] = search.Role
+	}
+	if search.Suspended != nil {
+		if *search.Suspended {
+			filter["suspended"] = true
+		} else {
+			filter["suspended"] = bson.M{"$ne": true}
+		}
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((search.Page - 1) * search.Limit)).
+		SetLimit(int64(search.Limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// CountByRole counts users with the given role
+func (r *UserRepository) CountByRole(role string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return r.collection.CountDocuments(ctx, bson.M{"role": role})
+}
+
+// SetRole changes the role of a user
+func (r *UserRepository) SetRole(userID string, role string) error {
+	return r.updateByID(userID, bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}})
+}
+
+// Suspend blocks a user from logging in
+func (r *UserRepository) Suspend(userID string, reason string) error {
+	now := time.Now()
+	return r.updateByID(userID, bson.M{"$set": bson.M{
+		"suspended":      true,
+		"suspended_at":   now,
+		"suspend_reason": reason,
+		"updated_at":     now,
+	}})
+}
+
+// Unsuspend lets a suspended user log in again
+func (r *UserRepository) Unsuspend(userID string) error {
+	return r.updateByID(userID, bson.M{
+		"$set":   bson.M{"suspended": false, "updated_at": time.Now()},
+		"$unset": bson.M{"suspended_at": "", "suspend_reason": ""},
+	})
+}
+
+// RequirePasswordReset makes the user set a new password (via forgot password) before the next login
+func (r *UserRepository) RequirePasswordReset(userID string) error {
+	return r.updateByID(userID, bson.M{"$set": bson.M{"password_reset_required": true, "updated_at": time.Now()}})
+}
+
 // Delete removes a user from the database
 func (r *UserRepository) Delete(userID string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
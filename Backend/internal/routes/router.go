@@ -5,64 +5,114 @@ import (
 
 	"github.com/user/Lotterich/internal/handlers"
 	"github.com/user/Lotterich/internal/middleware"
+	"github.com/user/Lotterich/internal/permissions"
+	"github.com/user/Lotterich/internal/repositories"
 )
 
 // SetupRoutes configures all the routes for the application
-func SetupRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, collectionHandler *handlers.CollectionHandler, statisticsHandler *handlers.StatisticsHandler) {
+func SetupRoutes(router *gin.Engine, sessionRepo *repositories.SessionRepository, authHandler *handlers.AuthHandler, collectionHandler *handlers.CollectionHandler, statisticsHandler *handlers.StatisticsHandler, drawHandler *handlers.DrawHandler, adminUserHandler *handlers.AdminUserHandler, auditHandler *handlers.AuditHandler, outboxHandler *handlers.OutboxHandler, telegramHandler *handlers.TelegramHandler, lineHandler *handlers.LineHandler, preferencesHandler *handlers.PreferencesHandler) {
 	// API group
 	api := router.Group("/api")
 
 	// Public routes
 	api.POST("/auth/register", authHandler.Register)
 	api.POST("/auth/login", authHandler.Login)
+	api.POST("/auth/login/2fa", authHandler.LoginTwoFactor)
+	api.POST("/auth/refresh", authHandler.Refresh)
+	api.POST("/auth/logout", authHandler.Logout)
 	api.POST("/auth/forgot-password", authHandler.ForgotPassword)
 	api.POST("/auth/verify-otp", authHandler.VerifyOTP)
 	api.POST("/auth/reset-password", authHandler.ResetPassword)
+	api.POST("/auth/verify-email", authHandler.VerifyEmail)
+	api.POST("/auth/resend-verification", authHandler.ResendVerification)
 	api.GET("/statistics/all", statisticsHandler.GetAllStatisticsPublic)
+	api.GET("/statistics/analytics", statisticsHandler.GetAnalytics)
+	api.GET("/draws/next", drawHandler.GetNext)
+	api.GET("/draws/calendar", drawHandler.GetCalendar)
+	api.POST("/telegram/webhook", telegramHandler.Webhook)
+	api.POST("/line/webhook", lineHandler.Webhook)
 
 	// Protected routes
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(sessionRepo))
 	{
 		// User routes
 		protected.GET("/users/me", authHandler.GetCurrentUser)
 		protected.PATCH("/users/me", authHandler.UpdateCurrentUser)
 		protected.POST("/users/change-password", authHandler.ChangePassword)
 		protected.DELETE("/users/me", authHandler.DeleteAccount)
+		protected.POST("/users/me/otp", authHandler.RequestOTP)
+		protected.POST("/users/me/email", authHandler.ChangeEmail)
+		protected.POST("/users/me/2fa/setup", authHandler.SetupTwoFactor)
+		protected.POST("/users/me/2fa/enable", authHandler.EnableTwoFactor)
+		protected.POST("/users/me/2fa/disable", authHandler.DisableTwoFactor)
+		protected.POST("/users/me/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+		protected.GET("/users/me/sessions", authHandler.GetSessions)
+		protected.DELETE("/users/me/sessions", authHandler.RevokeOtherSessions)
+		protected.DELETE("/users/me/sessions/:id", authHandler.RevokeSession)
+		protected.GET("/users/me/preferences", preferencesHandler.GetPreferences)
+		protected.PATCH("/users/me/preferences", preferencesHandler.UpdatePreferences)
+		protected.POST("/users/me/telegram/link", telegramHandler.CreateLink)
+		protected.DELETE("/users/me/telegram", telegramHandler.Unlink)
+		protected.POST("/users/me/line/link", lineHandler.CreateLink)
+		protected.DELETE("/users/me/line", lineHandler.Unlink)
 
 		// Collection routes
 		protected.GET("/collection", collectionHandler.GetAll)
-		protected.POST("/collection", collectionHandler.Create)
-		protected.PUT("/collection/:id", collectionHandler.Update)
-		protected.DELETE("/collection/:id", collectionHandler.Delete)
+		protected.GET("/collection/summary", collectionHandler.GetSummary)
+		protected.GET("/collection/export", collectionHandler.Export)
 
 		// Statistics routes for regular users
 		protected.GET("/statistics/latest", statisticsHandler.GetLatestStatistics)
 	}
 
-	// Admin routes
+	// Routes that need a verified email
+	verified := protected.Group("")
+	verified.Use(middleware.RequireVerifiedEmail())
+	{
+		verified.POST("/collection", collectionHandler.Create)
+		verified.POST("/collection/import", collectionHandler.Import)
+		verified.PUT("/collection/:id", collectionHandler.Update)
+		verified.DELETE("/collection/:id", collectionHandler.Delete)
+	}
+
+	// Admin routes; each route declares the permission it needs
 	admin := protected.Group("/admin")
-	admin.Use(AdminOnly())
+	admin.Use(middleware.RequirePermission(permissions.AdminPanel))
 	{
 		admin.GET("/manage", func(c *gin.Context) {
 			c.JSON(200, gin.H{"message": "ยินดีต้อนรับ Admin!"})
 		})
+		canReadStats := middleware.RequirePermission(permissions.StatisticsRead)
+		canWriteStats := middleware.RequirePermission(permissions.StatisticsWrite)
+		canWriteDraws := middleware.RequirePermission(permissions.DrawsWrite)
+		canReadUsers := middleware.RequirePermission(permissions.UsersRead)
+		canWriteUsers := middleware.RequirePermission(permissions.UsersWrite)
 		// Statistics routes
-		admin.GET("/statistics", statisticsHandler.GetAllStatistics)
-		admin.POST("/statistics", statisticsHandler.CreateStatistics)
-		admin.PUT("/statistics/:id", statisticsHandler.UpdateStatistics)
-		admin.DELETE("/statistics/:id", statisticsHandler.DeleteStatistics)
-	}
-}
-
-func AdminOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get("userRole")
-		if !exists || role != "admin" {
-			c.JSON(403, gin.H{"error": "สำหรับ Admin เท่านั้น"})
-			c.Abort()
-			return
-		}
-		c.Next()
+		admin.GET("/statistics", canReadStats, statisticsHandler.GetAllStatistics)
+		admin.GET("/statistics/export", canReadStats, statisticsHandler.ExportStatistics)
+		admin.POST("/statistics", canWriteStats, statisticsHandler.CreateStatistics)
+		admin.PUT("/statistics/:id", canWriteStats, statisticsHandler.UpdateStatistics)
+		admin.DELETE("/statistics/:id", canWriteStats, statisticsHandler.DeleteStatistics)
+		admin.GET("/statistics/:id/revisions", canReadStats, statisticsHandler.GetRevisions)
+		admin.POST("/statistics/:id/revisions/:version/rollback", canWriteStats, statisticsHandler.RollbackStatistics)
+		admin.GET("/recheck-jobs", canReadStats, statisticsHandler.GetRecheckJobs)
+		admin.GET("/recheck-jobs/:id", canReadStats, statisticsHandler.GetRecheckJob)
+		// Draw schedule exception routes
+		admin.GET("/draws/exceptions", canReadStats, drawHandler.GetExceptions)
+		admin.POST("/draws/exceptions", canWriteDraws, drawHandler.CreateException)
+		admin.DELETE("/draws/exceptions/:id", canWriteDraws, drawHandler.DeleteException)
+		// User management routes
+		admin.GET("/users", canReadUsers, adminUserHandler.GetUsers)
+		admin.GET("/users/:id", canReadUsers, adminUserHandler.GetUser)
+		admin.PATCH("/users/:id/role", canWriteUsers, adminUserHandler.ChangeRole)
+		admin.POST("/users/:id/suspend", canWriteUsers, adminUserHandler.Suspend)
+		admin.POST("/users/:id/unsuspend", canWriteUsers, adminUserHandler.Unsuspend)
+		admin.POST("/users/:id/force-password-reset", canWriteUsers, adminUserHandler.ForcePasswordReset)
+		// Audit log
+		admin.GET("/audit-log", middleware.RequirePermission(permissions.AuditRead), auditHandler.GetAuditLog)
+		// Notification outbox
+		admin.GET("/outbox", middleware.RequirePermission(permissions.OutboxRead), outboxHandler.GetOutbox)
+		admin.POST("/outbox/:id/replay", middleware.RequirePermission(permissions.OutboxWrite), outboxHandler.ReplayOutbox)
 	}
 }
@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+)
+
+// CommandFunc answers one command. The text it returns is sent back to the
+// chat; an empty text sends nothing
+type CommandFunc func(ctx context.Context, msg *Message, args []string) string
+
+type command struct {
+	usage       string
+	description string
+	run         CommandFunc
+}
+
+// Router sends bot commands to their handlers. /help is answered by the
+// router itself with the list of registered commands
+type Router struct {
+	commands map[string]command
+	order    []string
+}
+
+// NewRouter creates an empty router
+func NewRouter() *Router {
+	return &Router{commands: make(map[string]command)}
+}
+
+// Handle registers fn for the command name (without the slash). usage shows
+// the arguments in /help (e.g. "123456 [YYYY-MM-DD]"); a command with no
+// description is left out of /help
+func (r *Router) Handle(name, usage, description string, fn CommandFunc) {
+	name = strings.ToLower(name)
+	if _, ok := r.commands[name]; !ok {
+		r.order = append(r.order, name)
+	}
+	r.commands[name] = command{usage: usage, description: description, run: fn}
+}
+
+// Dispatch runs the command in msg and returns the reply. ok is false when the
+// message is not a command, which bots should leave unanswered
+func (r *Router) Dispatch(ctx context.Context, msg *Message) (reply string, ok bool) {
+	name, args, ok := ParseCommand(msg.Text)
+	if !ok {
+		return "", false
+	}
+	if cmd, found := r.commands[name]; found {
+		return cmd.run(ctx, msg, args), true
+	}
+	if name == "help" {
+		return r.Help(), true
+	}
+	if !msg.Private() {
+		// ในกลุ่มอาจเป็นคำสั่งของบอทอื่น
+		return "", true
+	}
+	return "ไม่รู้จักคำสั่ง /" + name + "\n\n" + r.Help(), true
+}
+
+// Help lists the registered commands in the order they were added
+func (r *Router) Help() string {
+	var b strings.Builder
+	b.WriteString("คำสั่งที่ใช้ได้:")
+	for _, name := range r.order {
+		cmd := r.commands[name]
+		if cmd.description == "" {
+			continue
+		}
+		b.WriteString("\n/" + name)
+		if cmd.usage != "" {
+			b.WriteString(" " + cmd.usage)
+		}
+		b.WriteString(" - " + cmd.description)
+	}
+	return b.String()
+}
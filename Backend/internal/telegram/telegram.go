@@ -0,0 +1,90 @@
+// Package telegram reads updates that the Telegram Bot API posts to the
+// webhook, and builds bot deep links.
+package telegram
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SecretHeader carries the secret_token given to setWebhook; Telegram sends it
+// with every update so the webhook can reject anything else
+const SecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Update is one incoming update. Only new messages are answered; edits and
+// other kinds are ignored
+type Update struct {
+	UpdateID      int64    `json:"update_id"`
+	Message       *Message `json:"message"`
+	EditedMessage *Message `json:"edited_message"`
+}
+
+// Command returns the message the bot should answer, or nil for updates that
+// aren't new messages from a person (edits, other bots, callbacks, ...)
+func (u *Update) Command() *Message {
+	if u.Message == nil || u.Message.FromBot() {
+		return nil
+	}
+	return u.Message
+}
+
+// Message is a message sent to the bot
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+}
+
+// Chat is the chat a message came from
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"` // private, group, supergroup or channel
+}
+
+// User is the sender of a message
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username"`
+}
+
+// ChatID returns the chat ID as used by notify.Message.To
+func (m *Message) ChatID() string {
+	return strconv.FormatInt(m.Chat.ID, 10)
+}
+
+// FromBot reports whether the message was sent by a bot
+func (m *Message) FromBot() bool {
+	return m.From != nil && m.From.IsBot
+}
+
+// Private reports whether the message came from a one-to-one chat with the bot
+func (m *Message) Private() bool {
+	return m.Chat.Type == "private"
+}
+
+// ParseCommand splits a command such as "/start abc" or "/start@LotterichBot abc"
+// into its name ("start") and arguments. ok is false when text isn't a command
+func ParseCommand(text string) (name string, args []string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, false
+	}
+	name = strings.TrimPrefix(fields[0], "/")
+	if at := strings.IndexByte(name, '@'); at >= 0 {
+		name = name[:at]
+	}
+	if name == "" {
+		return "", nil, false
+	}
+	return strings.ToLower(name), fields[1:], true
+}
+
+// DeepLink returns the link that opens a chat with the bot and sends
+// "/start <payload>". payload may only use A-Z, a-z, 0-9, _ and - (max 64)
+func DeepLink(botUsername, payload string) string {
+	return "https://t.me/" + botUsername + "?start=" + payload
+}
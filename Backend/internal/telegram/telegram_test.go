@@ -0,0 +1,195 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loadUpdate(t *testing.T, name string) Update {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var update Update
+	if err := json.Unmarshal(data, &update); err != nil {
+		t.Fatalf("unmarshal %s: %v", name, err)
+	}
+	return update
+}
+
+// testRouter answers every registered command with its name and arguments
+func testRouter() *Router {
+	r := NewRouter()
+	echo := func(ctx context.Context, msg *Message, args []string) string {
+		name, _, _ := ParseCommand(msg.Text)
+		return strings.TrimSpace(name + " " + strings.Join(args, " "))
+	}
+	r.Handle("start", "", "", echo)
+	r.Handle("check", "123456 [YYYY-MM-DD]", "ตรวจเลข", echo)
+	r.Handle("latest", "", "ผลรางวัลงวดล่าสุด", echo)
+	return r
+}
+
+func TestUpdates(t *testing.T) {
+	tests := []struct {
+		file      string
+		command   bool // Update.Command returns a message
+		name      string
+		args      []string
+		isCommand bool // ParseCommand ok
+		chatID    string
+		private   bool
+		reply     string
+	}{
+		{
+			file: "private_start.json", command: true,
+			name: "start", args: []string{"Yx3kQ9b_Lw2sVd-07HfPq1RtZm8cNeAu"}, isCommand: true,
+			chatID: "52814730", private: true,
+			reply: "start Yx3kQ9b_Lw2sVd-07HfPq1RtZm8cNeAu",
+		},
+		{
+			file: "group_check.json", command: true,
+			name: "check", args: []string{"123456"}, isCommand: true,
+			chatID: "-1001948273615", private: false,
+			reply: "check 123456",
+		},
+		{
+			file: "photo_no_text.json", command: true,
+			chatID: "52814730", private: true,
+		},
+		{
+			file:      "edited_message.json",
+			name:      "check",
+			args:      []string{"654321"},
+			isCommand: true,
+			chatID:    "52814730",
+			private:   true,
+		},
+		{
+			file:      "from_bot.json",
+			name:      "latest",
+			args:      []string{},
+			isCommand: true,
+			chatID:    "-1001948273615",
+		},
+	}
+	router := testRouter()
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			update := loadUpdate(t, tt.file)
+			if update.UpdateID == 0 {
+				t.Fatal("update_id not parsed")
+			}
+
+			msg := update.Command()
+			if (msg != nil) != tt.command {
+				t.Fatalf("Command() = %v, want a message: %v", msg, tt.command)
+			}
+			// ข้อความที่ไม่ต้องตอบยังต้องแปลงได้ถูกต้อง
+			raw := update.Message
+			if raw == nil {
+				raw = update.EditedMessage
+			}
+			if raw == nil {
+				t.Fatal("update has no message")
+			}
+
+			name, args, ok := ParseCommand(raw.Text)
+			if ok != tt.isCommand {
+				t.Fatalf("ParseCommand(%q) ok = %v, want %v", raw.Text, ok, tt.isCommand)
+			}
+			if tt.isCommand && (name != tt.name || !reflect.DeepEqual(args, tt.args)) {
+				t.Errorf("ParseCommand(%q) = %q %v, want %q %v", raw.Text, name, args, tt.name, tt.args)
+			}
+			if raw.ChatID() != tt.chatID {
+				t.Errorf("ChatID() = %s, want %s", raw.ChatID(), tt.chatID)
+			}
+			if raw.Private() != tt.private {
+				t.Errorf("Private() = %v, want %v", raw.Private(), tt.private)
+			}
+
+			if msg == nil {
+				return
+			}
+			reply, ok := router.Dispatch(context.Background(), msg)
+			if ok != tt.isCommand || reply != tt.reply {
+				t.Errorf("Dispatch = %q, %v; want %q, %v", reply, ok, tt.reply, tt.isCommand)
+			}
+		})
+	}
+}
+
+func TestEditedMessageIsIgnored(t *testing.T) {
+	update := loadUpdate(t, "edited_message.json")
+	if update.Message != nil || update.EditedMessage == nil {
+		t.Fatalf("edited_message parsed as %+v", update)
+	}
+	if update.EditedMessage.Text != "/check 654321" {
+		t.Errorf("edited text = %q", update.EditedMessage.Text)
+	}
+	if update.Command() != nil {
+		t.Error("Command() answered an edited message")
+	}
+}
+
+func TestFromBot(t *testing.T) {
+	if !loadUpdate(t, "from_bot.json").Message.FromBot() {
+		t.Error("bot sender not detected")
+	}
+	if loadUpdate(t, "group_check.json").Message.FromBot() {
+		t.Error("person detected as bot")
+	}
+	if (&Message{}).FromBot() {
+		t.Error("message without sender detected as bot")
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text string
+		name string
+		args []string
+		ok   bool
+	}{
+		{"/check 123456", "check", []string{"123456"}, true},
+		{"/CHECK@LotterichBot  123456   2026-10-16", "check", []string{"123456", "2026-10-16"}, true},
+		{"/latest", "latest", []string{}, true},
+		{"  /help  ", "help", []string{}, true},
+		{"123456", "", nil, false},
+		{"/", "", nil, false},
+		{"/@LotterichBot", "", nil, false},
+		{"", "", nil, false},
+	}
+	for _, tt := range tests {
+		name, args, ok := ParseCommand(tt.text)
+		if ok != tt.ok || name != tt.name || (ok && !reflect.DeepEqual(args, tt.args)) {
+			t.Errorf("ParseCommand(%q) = %q %v %v, want %q %v %v", tt.text, name, args, ok, tt.name, tt.args, tt.ok)
+		}
+	}
+}
+
+func TestDispatchUnknownCommand(t *testing.T) {
+	router := testRouter()
+	group := &Message{Chat: Chat{ID: -100, Type: "supergroup"}, Text: "/weather@OtherBot"}
+	if reply, ok := router.Dispatch(context.Background(), group); !ok || reply != "" {
+		t.Errorf("group: Dispatch = %q, %v; want silence", reply, ok)
+	}
+
+	private := &Message{Chat: Chat{ID: 1, Type: "private"}, Text: "/weather"}
+	reply, ok := router.Dispatch(context.Background(), private)
+	if !ok || !strings.HasPrefix(reply, "ไม่รู้จักคำสั่ง /weather") || !strings.Contains(reply, "/check 123456 [YYYY-MM-DD]") {
+		t.Errorf("private: Dispatch = %q, %v", reply, ok)
+	}
+
+	help, _ := router.Dispatch(context.Background(), &Message{Chat: Chat{Type: "private"}, Text: "/help"})
+	if strings.Contains(help, "/start") || !strings.Contains(help, "/latest - ผลรางวัลงวดล่าสุด") {
+		t.Errorf("help = %q", help)
+	}
+}
@@ -0,0 +1,64 @@
+package throttle
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Guard protects one action (e.g. "login") with a per-account and a per-IP limiter
+type Guard struct {
+	scope   string
+	account *Limiter
+	ip      *Limiter
+}
+
+// NewGuard creates a Guard whose keys are prefixed with scope
+func NewGuard(scope string, store Store, accountPolicy, ipPolicy Policy) *Guard {
+	return &Guard{
+		scope:   scope,
+		account: NewLimiter(store, accountPolicy),
+		ip:      NewLimiter(store, ipPolicy),
+	}
+}
+
+// Allow returns how long the caller has to wait before trying again
+func (g *Guard) Allow(ctx context.Context, account, ip string) (time.Duration, error) {
+	accountWait, err := g.account.Allow(ctx, g.accountKey(account))
+	if err != nil {
+		return 0, err
+	}
+	ipWait, err := g.ip.Allow(ctx, g.ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	return max(accountWait, ipWait), nil
+}
+
+// Fail records a failed attempt for both the account and the IP and returns
+// the longest lockout it caused
+func (g *Guard) Fail(ctx context.Context, account, ip string) (time.Duration, error) {
+	accountWait, err := g.account.Fail(ctx, g.accountKey(account))
+	if err != nil {
+		return 0, err
+	}
+	ipWait, err := g.ip.Fail(ctx, g.ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	return max(accountWait, ipWait), nil
+}
+
+// Succeed clears the account counter. The IP counter is kept so that one valid
+// account can't be used to reset the counter of an attacker's IP
+func (g *Guard) Succeed(ctx context.Context, account string) error {
+	return g.account.Reset(ctx, g.accountKey(account))
+}
+
+func (g *Guard) accountKey(account string) string {
+	return g.scope + ":account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func (g *Guard) ipKey(ip string) string {
+	return g.scope + ":ip:" + ip
+}
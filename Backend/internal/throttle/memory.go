@@ -0,0 +1,89 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps counters in process memory. Counters are lost on restart
+// and are not shared between instances, so use it for development or a single instance
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastPrune time.Time
+}
+
+type memoryEntry struct {
+	Entry
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return Entry{}, nil
+	}
+	return entry.Entry, nil
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, now time.Time, window time.Duration) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(now)
+	entry := s.entries[key]
+	if now.Sub(entry.LastFailure) > window {
+		entry.Failures = 0
+	}
+	entry.Failures++
+	entry.LastFailure = now
+	if expiresAt := now.Add(window); expiresAt.After(entry.expiresAt) {
+		entry.expiresAt = expiresAt
+	}
+	s.entries[key] = entry
+	return entry.Entry, nil
+}
+
+func (s *MemoryStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entries[key]
+	entry.LockedUntil = until
+	if until.After(entry.expiresAt) {
+		entry.expiresAt = until
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// pruneLocked drops expired entries so the map doesn't grow forever
+// It runs at most once a minute
+func (s *MemoryStore) pruneLocked(now time.Time) {
+	if now.Sub(s.lastPrune) < time.Minute {
+		return
+	}
+	s.lastPrune = now
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package throttle counts failed attempts (login, OTP) per key and locks a key
+// out with exponential backoff once it fails too often.
+package throttle
+
+import (
+	"context"
+	"time"
+)
+
+// Entry is the counter state of one key
+type Entry struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store keeps counter state. Implementations must make Increment atomic
+// because several requests for the same key can arrive at once
+type Store interface {
+	// Get returns the entry of a key, or a zero Entry when there is none
+	Get(ctx context.Context, key string) (Entry, error)
+	// Increment adds one failure and returns the new entry. The count starts
+	// over when the previous failure is older than window
+	Increment(ctx context.Context, key string, now time.Time, window time.Duration) (Entry, error)
+	// Lock blocks the key until the given time
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets the key
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy describes when and for how long a key is locked out
+type Policy struct {
+	// Free is the number of failures allowed before any lockout
+	Free int
+	// BaseDelay is the first lockout; every further failure doubles it
+	BaseDelay time.Duration
+	// MaxDelay caps the lockout
+	MaxDelay time.Duration
+	// Window is how long failures are remembered
+	Window time.Duration
+}
+
+// Delay returns the lockout after the given number of failures
+func (p Policy) Delay(failures int) time.Duration {
+	if failures <= p.Free {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.Free + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Limiter applies one Policy to keys kept in a Store
+type Limiter struct {
+	store  Store
+	policy Policy
+}
+
+// NewLimiter creates a Limiter
+func NewLimiter(store Store, policy Policy) *Limiter {
+	return &Limiter{store: store, policy: policy}
+}
+
+// Allow returns how long the caller has to wait before trying the key again
+// Zero means the attempt may go ahead
+func (l *Limiter) Allow(ctx context.Context, key string) (time.Duration, error) {
+	entry, err := l.store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return retryAfter(entry.LockedUntil, time.Now()), nil
+}
+
+// Fail records a failed attempt and returns the lockout it caused, if any
+func (l *Limiter) Fail(ctx context.Context, key string) (time.Duration, error) {
+	now := time.Now()
+	entry, err := l.store.Increment(ctx, key, now, l.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	delay := l.policy.Delay(entry.Failures)
+	if delay == 0 {
+		return 0, nil
+	}
+	if err := l.store.Lock(ctx, key, now.Add(delay)); err != nil {
+		return 0, err
+	}
+	return delay, nil
+}
+
+// Reset clears the failures of a key, e.g. after a successful login
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Reset(ctx, key)
+}
+
+func retryAfter(lockedUntil, now time.Time) time.Duration {
+	if lockedUntil.After(now) {
+		return lockedUntil.Sub(now)
+	}
+	return 0
+}
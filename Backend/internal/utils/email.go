@@ -1,37 +0,0 @@
-package utils
-
-import (
-	"fmt"
-	"os"
-
-	"gopkg.in/gomail.v2"
-)
-
-func SendEmail(to, subject, body string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", "Lotterich Support <"+os.Getenv("SMTP_FROM")+">")
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/plain", body)
-
-	d := gomail.NewDialer(
-		os.Getenv("SMTP_HOST"),
-		getEnvAsInt("SMTP_PORT", 587),
-		os.Getenv("SMTP_USER"),
-		os.Getenv("SMTP_PASS"),
-	)
-	return d.DialAndSend(m)
-}
-
-func getEnvAsInt(key string, defaultVal int) int {
-	valStr := os.Getenv(key)
-	if valStr == "" {
-		return defaultVal
-	}
-	var val int
-	_, err := fmt.Sscanf(valStr, "%d", &val)
-	if err != nil {
-		return defaultVal
-	}
-	return val
-}
@@ -14,26 +14,31 @@ type CustomClaims struct {
 	Name   string `json:"name"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// SessionID ผูก access token กับ session ที่ login เพื่อให้เพิกถอนได้ทันที
+	SessionID string `json:"sid"`
+	// EmailVerified ผู้ใช้ที่ยังไม่ยืนยันอีเมลจะใช้งานได้บางส่วนเท่านั้น
+	EmailVerified bool `json:"emailVerified"`
+	// Permissions คือสิทธิ์ของ role ณ ตอนออก token (เปลี่ยน role แล้ว session เดิมจะถูกเพิกถอน)
+	Permissions []string `json:"perms,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT creates a new JWT token for a user
-func GenerateJWT(userID, name, email, role string, rememberMe bool) (string, error) {
+// GenerateJWT creates a new short-lived access token for a user
+// Long sessions are kept alive with refresh tokens (see RefreshTokenTTL)
+func GenerateJWT(userID, name, email, role, sessionID string, emailVerified bool, permissions []string) (string, error) {
 	// Get secret key from environment or use default for development
 	secretKey := getSecretKey()
-	var expiresIn time.Duration
-	if rememberMe {
-		expiresIn = 30 * 24 * time.Hour // 30 วัน
-	} else {
-		expiresIn = 24 * time.Hour // 24 ชม.
-	}
+	expiresIn := getExpirationDuration()
 
 	// Create claims
 	claims := CustomClaims{
-		UserID: userID,
-		Name:   name,
-		Email:  email,
-		Role:   role,
+		UserID:        userID,
+		Name:          name,
+		Email:         email,
+		Role:          role,
+		SessionID:     sessionID,
+		EmailVerified: emailVerified,
+		Permissions:   permissions,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -95,18 +100,31 @@ func getSecretKey() string {
 	return secretKey
 }
 
-// getExpirationDuration retrieves the JWT expiration duration from environment or uses a default
+// getExpirationDuration retrieves the access token lifetime from environment or uses a default
 func getExpirationDuration() time.Duration {
 	expiresIn := os.Getenv("JWT_EXPIRATION")
 	if expiresIn == "" {
-		// Default to 24 hours
-		return 24 * time.Hour
+		// Default to 15 minutes
+		return 15 * time.Minute
 	}
 
 	duration, err := time.ParseDuration(expiresIn)
 	if err != nil {
-		// Fall back to 24 hours if parsing fails
-		return 24 * time.Hour
+		// Fall back to 15 minutes if parsing fails
+		return 15 * time.Minute
 	}
 	return duration
 }
+
+// AccessTokenTTL returns how long an access token issued now stays valid
+func AccessTokenTTL() time.Duration {
+	return getExpirationDuration()
+}
+
+// RefreshTokenTTL returns the refresh token lifetime of a login
+func RefreshTokenTTL(rememberMe bool) time.Duration {
+	if rememberMe {
+		return 30 * 24 * time.Hour // 30 วัน
+	}
+	return 24 * time.Hour // 24 ชม.
+}
@@ -1,18 +1,42 @@
 package utils
 
 import (
-	"math/rand"
-	"strconv"
-	"time"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"os"
 )
 
-func GenerateOTP(length int) string {
-	rand.Seed(time.Now().UnixNano())
-	min := int64(1)
-	for i := 1; i < length; i++ {
-		min *= 10
+// GenerateOTP creates a numeric code of the given length using crypto/rand
+// Every digit is uniformly random, so codes may start with 0
+func GenerateOTP(length int) (string, error) {
+	digits := make([]byte, length)
+	ten := big.NewInt(10)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, ten)
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
 	}
-	max := min*10 - 1
-	otp := rand.Int63n(max-min+1) + min
-	return strconv.FormatInt(otp, 10)
+	return string(digits), nil
+}
+
+// HashOTP returns the keyed hash that is stored for a code
+// The email and purpose are part of the hash, so a code can't be replayed for
+// another account or purpose, and a leaked hash can't be brute-forced without the key
+func HashOTP(email, purpose, code string) string {
+	mac := hmac.New(sha256.New, []byte(getOTPSecret()))
+	mac.Write([]byte(email + "\x00" + purpose + "\x00" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// getOTPSecret retrieves the OTP hashing key from environment or falls back to the JWT secret
+func getOTPSecret() string {
+	if secret := os.Getenv("OTP_SECRET"); secret != "" {
+		return secret
+	}
+	return getSecretKey()
 }
@@ -18,6 +18,7 @@ import OverviewPage from './pages/OverviewPage'
 import StatisticsPage from './pages/StatisticsPage'
 import ManagePage from './pages/ManagePage'
 import ForgotPasswordPage from './pages/ForgotPasswordPage'
+import VerifyEmailPage from './pages/VerifyEmailPage'
 import ResetPassword from './pages/ResetPassword'
 
 function App() {
@@ -31,8 +32,9 @@ function App() {
             <Route path="/login" element={<LoginPage />} />
             <Route path="/register" element={<RegisterPage />} />
             <Route path="/forgot-password" element={<ForgotPasswordPage />} />
+            <Route path="/verify-email" element={<VerifyEmailPage />} />
             <Route path="/statistics" element={<StatisticsPage/>} />
-            <Route element={<ProtectedRoute role="admin" />}>
+            <Route element={<ProtectedRoute permission="admin:panel" />}>
               <Route path="/admin/manage" element={<ManagePage />} />
             </Route>
             <Route element={<ProtectedRoute role="user" />}>
@@ -2,28 +2,36 @@ import { useState } from 'react'
 import { useForm } from 'react-hook-form'
 import { useNavigate } from 'react-router-dom'
 import { toast } from 'react-toastify'
-import { useAuth } from '../../context/AuthContext'
+import { useAuth, hasPermission } from '../../context/AuthContext'
 
 const LoginForm = () => {
   const [isSubmitting, setIsSubmitting] = useState(false)
   const [error, setError] = useState('')
-  const { login } = useAuth()
+  const { login, completeTwoFactorLogin } = useAuth()
   const navigate = useNavigate()
   const { register, handleSubmit, formState: { errors } } = useForm()
   const [showPassword, setShowPassword] = useState(false)
   const [rememberMe, setRememberMe] = useState(false)
+  const [twoFactor, setTwoFactor] = useState(null)
+  const [code, setCode] = useState('')
+
+  const goHome = (user) => {
+    if (hasPermission(user, 'admin:panel')) {
+      navigate('/admin/manage')
+    } else {
+      navigate('/home')
+    }
+  }
 
   const onSubmit = async (data) => {
     setIsSubmitting(true)
     setError('')
     try {
       const user = await login({ ...data, rememberMe })
-      if (user) {
-        if (user.role === 'admin') {
-          navigate('/admin/manage')
-        } else {
-          navigate('/home')
-        }
+      if (user?.twoFactorRequired) {
+        setTwoFactor(user)
+      } else if (user) {
+        goHome(user)
       }
     } catch (err) {
       setError(err.response?.data?.message || 'Login failed. Please try again.')
@@ -32,6 +40,41 @@ const LoginForm = () => {
     }
   }
 
+  const onSubmitCode = async (e) => {
+    e.preventDefault()
+    setIsSubmitting(true)
+    try {
+      const user = await completeTwoFactorLogin({ ...twoFactor, code })
+      if (user) {
+        goHome(user)
+      }
+    } finally {
+      setIsSubmitting(false)
+    }
+  }
+
+  if (twoFactor) {
+    return (
+      <form onSubmit={onSubmitCode} autoComplete="off">
+        <h2 style={{ color: '#ffd700', marginBottom: '2rem', textAlign: 'center', fontWeight: 600 }}>ยืนยันตัวตน</h2>
+        <div className="form-group">
+          <label className="form-label">รหัสจากแอป Authenticator หรือรหัสกู้คืน</label>
+          <input
+            type="text"
+            placeholder="123456"
+            autoComplete="one-time-code"
+            autoFocus
+            value={code}
+            onChange={e => setCode(e.target.value)}
+          />
+        </div>
+        <button type="submit" className="login-button" disabled={isSubmitting || !code}>
+          {isSubmitting ? 'กำลังตรวจสอบ...' : 'ยืนยัน'}
+        </button>
+      </form>
+    )
+  }
+
   return (
     <form onSubmit={handleSubmit(onSubmit)} autoComplete="on">
       <h2 style={{ color: '#ffd700', marginBottom: '2rem', textAlign: 'center', fontWeight: 600 }}>เข้าสู่ระบบ</h2>
@@ -26,7 +26,7 @@ const RegisterForm = () => {
     try {
       const success = await registerUser(data)
       if (success) {
-        navigate('/login')
+        navigate('/verify-email', { state: { email: data.email } })
       } else {
         setError('ขออภัย มีข้อผิดพลาดเกิดขึ้น กรุณาลองใหม่อีกครั้ง')
       }
@@ -0,0 +1,189 @@
+import { useEffect, useState } from 'react'
+import { toast } from 'react-toastify'
+import authService from '../../services/authService'
+
+const channelLabels = { email: 'อีเมล', telegram: 'Telegram', line: 'LINE' }
+const eventLabels = {
+  ticketWon: 'สลากของฉันถูกรางวัล',
+  newDraw: 'ผลรางวัลงวดใหม่',
+  weeklyDigest: 'สรุปประจำสัปดาห์ (ทุกวันจันทร์)',
+  securityAlerts: 'แจ้งเตือนความปลอดภัยของบัญชี'
+}
+
+// การตั้งค่าการแจ้งเตือนในหน้าโปรไฟล์ ทุกการเปลี่ยนแปลงบันทึกทันที
+const NotificationPreferences = () => {
+  const [prefs, setPrefs] = useState(null)
+  const [linked, setLinked] = useState({})
+  const [saving, setSaving] = useState(false)
+
+  const load = async () => {
+    try {
+      const data = await authService.getPreferences()
+      setPrefs(data.preferences)
+      setLinked(data.linked || {})
+    } catch (err) {
+      toast.error(err.message || 'Failed to fetch preferences')
+    }
+  }
+
+  useEffect(() => {
+    load()
+  }, [])
+
+  const save = async (changes) => {
+    try {
+      setSaving(true)
+      const data = await authService.updatePreferences(changes)
+      setPrefs(data.preferences)
+      setLinked(data.linked || {})
+    } catch (err) {
+      toast.error(err.message || 'Update failed')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const handleLinkTelegram = async () => {
+    try {
+      setSaving(true)
+      const { link, code } = await authService.createTelegramLink()
+      window.open(link, '_blank', 'noopener')
+      toast.info(`กด Start ในแชทกับบอท (หรือส่ง /link ${code}) แล้วรีเฟรชหน้านี้`, { autoClose: false })
+    } catch (err) {
+      toast.error(err.message || 'Failed to create link')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const handleUnlinkTelegram = async () => {
+    try {
+      setSaving(true)
+      await authService.unlinkTelegram()
+      await load()
+      toast.success('ยกเลิกการเชื่อมต่อ Telegram แล้ว')
+    } catch (err) {
+      toast.error(err.message || 'Failed to unlink Telegram')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const handleLinkLine = async () => {
+    try {
+      setSaving(true)
+      const { link, code } = await authService.createLineLink()
+      window.open(link, '_blank', 'noopener')
+      toast.info(`ส่งข้อความ link ${code} ในแชทกับบอท LINE แล้วรีเฟรชหน้านี้`, { autoClose: false })
+    } catch (err) {
+      toast.error(err.message || 'Failed to create link')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const handleUnlinkLine = async () => {
+    try {
+      setSaving(true)
+      await authService.unlinkLine()
+      await load()
+      toast.success('ยกเลิกการเชื่อมต่อ LINE แล้ว')
+    } catch (err) {
+      toast.error(err.message || 'Failed to unlink LINE')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  if (!prefs) return null
+
+  return (
+    <div className="notification-preferences">
+      <div className="form-group">
+        <label className="form-label">ช่องทางการแจ้งเตือน</label>
+        {Object.entries(channelLabels).map(([channel, label]) => (
+          <label key={channel} className="checkbox-row">
+            <input
+              type="checkbox"
+              checked={prefs.channels[channel]}
+              onChange={e => save({ channels: { [channel]: e.target.checked } })}
+              disabled={saving}
+            />
+            {label}
+            {channel !== 'email' && !linked[channel] && <span className="hint"> (ยังไม่ได้เชื่อมต่อ)</span>}
+          </label>
+        ))}
+        {linked.telegram ? (
+          <button type="button" className="link-button" onClick={handleUnlinkTelegram} disabled={saving}>
+            ยกเลิกการเชื่อมต่อ Telegram
+          </button>
+        ) : (
+          <button type="button" className="link-button" onClick={handleLinkTelegram} disabled={saving}>
+            เชื่อมต่อ Telegram
+          </button>
+        )}
+        {linked.line ? (
+          <button type="button" className="link-button" onClick={handleUnlinkLine} disabled={saving}>
+            ยกเลิกการเชื่อมต่อ LINE
+          </button>
+        ) : (
+          <button type="button" className="link-button" onClick={handleLinkLine} disabled={saving}>
+            เชื่อมต่อ LINE
+          </button>
+        )}
+      </div>
+
+      <div className="form-group">
+        <label className="form-label">แจ้งเตือนเมื่อ</label>
+        {Object.entries(eventLabels).map(([event, label]) => (
+          <label key={event} className="checkbox-row">
+            <input
+              type="checkbox"
+              checked={prefs.events[event]}
+              onChange={e => save({ events: { [event]: e.target.checked } })}
+              disabled={saving}
+            />
+            {label}
+          </label>
+        ))}
+      </div>
+
+      <div className="form-group">
+        <label className="checkbox-row">
+          <input
+            type="checkbox"
+            checked={prefs.quietHours.enabled}
+            onChange={e => save({ quietHours: { enabled: e.target.checked } })}
+            disabled={saving}
+          />
+          งดแจ้งเตือนช่วงเวลา
+        </label>
+        <div className="quiet-hours">
+          <input
+            type="time"
+            value={prefs.quietHours.start}
+            onChange={e => save({ quietHours: { start: e.target.value } })}
+            disabled={saving || !prefs.quietHours.enabled}
+          />
+          <span>ถึง</span>
+          <input
+            type="time"
+            value={prefs.quietHours.end}
+            onChange={e => save({ quietHours: { end: e.target.value } })}
+            disabled={saving || !prefs.quietHours.enabled}
+          />
+        </div>
+      </div>
+
+      <div className="form-group">
+        <label className="form-label">ภาษาของการแจ้งเตือน</label>
+        <select value={prefs.language} onChange={e => save({ language: e.target.value })} disabled={saving}>
+          <option value="th">ไทย</option>
+          <option value="en">English</option>
+        </select>
+      </div>
+    </div>
+  )
+}
+
+export default NotificationPreferences
@@ -0,0 +1,51 @@
+// OTPInput shows one box per digit over a hidden numeric input
+const OTPInput = ({ value, onChange, length = 6 }) => {
+  const handleChange = (e) => {
+    const val = e.target.value.replace(/\D/g, '').slice(0, length)
+    onChange(val)
+  }
+  return (
+    <div style={{ position: 'relative', width: `${length * 2.5}rem`, margin: '0 auto' }}>
+      <div style={{ display: 'flex', gap: '0.5rem', justifyContent: 'center' }}>
+        {[...Array(length)].map((_, i) => (
+          <div
+            key={i}
+            style={{
+              borderBottom: '2px solid #ffd700',
+              width: '2rem',
+              height: '2.5rem',
+              textAlign: 'center',
+              fontSize: '1.5rem',
+              color: '#ffd700',
+              background: 'transparent',
+              position: 'relative',
+              transition: 'border-color 0.2s',
+            }}
+          >
+            {value[i] || ''}
+          </div>
+        ))}
+      </div>
+      <input
+        type="text"
+        inputMode="numeric"
+        autoFocus
+        value={value}
+        onChange={handleChange}
+        maxLength={length}
+        style={{
+          position: 'absolute',
+          left: 0,
+          top: 0,
+          width: '100%',
+          height: '2.5rem',
+          opacity: 0,
+          pointerEvents: 'auto',
+        }}
+        tabIndex={0}
+      />
+    </div>
+  )
+}
+
+export default OTPInput
@@ -1,7 +1,7 @@
 import { Navigate, Outlet, useLocation } from 'react-router-dom'
-import { useAuth } from '../../context/AuthContext'
+import { useAuth, hasPermission } from '../../context/AuthContext'
 
-const ProtectedRoute = ({ role }) => {
+const ProtectedRoute = ({ role, permission }) => {
   const { user, isAuthenticated, loading } = useAuth()
   const location = useLocation()
 
@@ -17,6 +17,10 @@ const ProtectedRoute = ({ role }) => {
     return <Navigate to="/notfound" replace />
   }
 
+  if (permission && !hasPermission(user, permission)) {
+    return <Navigate to="/notfound" replace />
+  }
+
   return <Outlet />
 }
 
@@ -1,6 +1,6 @@
 import { Link, useNavigate, useLocation } from 'react-router-dom'
 import { Navbar, Nav, Container } from 'react-bootstrap'
-import { useAuth } from '../../context/AuthContext'
+import { useAuth, hasPermission } from '../../context/AuthContext'
 import './Header.css'
 import { useRef, useState } from 'react'
 
@@ -38,7 +38,7 @@ const Header = () => {
     <>
       <Navbar expand="lg" className="navbar" expanded={expanded} onToggle={setExpanded}>
         <Container fluid>
-          <Navbar.Brand as={Link} to={isAuthenticated && user.role === 'admin' ? "/admin/manage" : "/"} onClick={handleNavClick}>
+          <Navbar.Brand as={Link} to={isAuthenticated && hasPermission(user, 'admin:panel') ? "/admin/manage" : "/"} onClick={handleNavClick}>
             <img src="/Image/Lotterich_Logo2.png" alt="Logo" className="logo" />
           </Navbar.Brand>
           <Navbar.Toggle aria-controls="basic-navbar-nav" />
@@ -62,7 +62,7 @@ const Header = () => {
               {!isAuthenticated && (
                 <Nav.Link as={Link} to="/" onClick={handleNavClick} className={location.pathname === '/' ? 'active' : ''}>Home</Nav.Link>
               )}
-              {isAuthenticated && user.role === 'admin' ? (
+              {isAuthenticated && hasPermission(user, 'admin:panel') ? (
                 <>
                   <Nav.Link as={Link} to="/admin/manage" onClick={handleNavClick} className={location.pathname === '/admin/manage' ? 'active' : ''}>Manage</Nav.Link>
                   <Nav.Link as={Link} to="/statistics" onClick={handleNavClick} className={location.pathname === '/statistics' ? 'active' : ''}>Statistics</Nav.Link>
@@ -7,6 +7,10 @@ const AuthContext = createContext(null)
 
 export const useAuth = () => useContext(AuthContext)
 
+// Permissions come from the API user ("permissions") or the decoded token ("perms")
+export const hasPermission = (user, permission) =>
+  !!user && (user.permissions || user.perms || []).includes(permission)
+
 export const AuthProvider = ({ children }) => {
   const [user, setUser] = useState(null)
   const [loading, setLoading] = useState(true)
@@ -23,38 +27,115 @@ export const AuthProvider = ({ children }) => {
     }
   }
 
+  const saveTokens = (response) => {
+    localStorage.setItem('token', response.token)
+    if (response.refreshToken) {
+      localStorage.setItem('refreshToken', response.refreshToken)
+    }
+  }
+
+  const clearTokens = () => {
+    localStorage.removeItem('token')
+    localStorage.removeItem('refreshToken')
+  }
+
+  // Exchange the stored refresh token for a new token pair
+  const refreshSession = async () => {
+    const refreshToken = localStorage.getItem('refreshToken')
+    if (!refreshToken) {
+      return false
+    }
+    try {
+      const response = await authService.refresh(refreshToken)
+      saveTokens(response)
+      return true
+    } catch (error) {
+      console.error('Refresh session error:', error)
+      clearTokens()
+      return false
+    }
+  }
+
   useEffect(() => {
-    const token = localStorage.getItem('token')
-    
-    if (token) {
-      try {
-        const decoded = jwtDecode(token)
-        
-        // Check if token is expired
-        if (decoded.exp * 1000 < Date.now()) {
-          localStorage.removeItem('token')
-          setUser(null)
-          toast.error('Session expired. Please login again.')
-        } else {
+    const restoreSession = async () => {
+      let token = localStorage.getItem('token')
+
+      if (token) {
+        try {
+          let decoded = jwtDecode(token)
+
+          // Access token expired: try the refresh token before giving up
+          if (decoded.exp * 1000 < Date.now()) {
+            if (await refreshSession()) {
+              token = localStorage.getItem('token')
+              decoded = jwtDecode(token)
+            } else {
+              setUser(null)
+              toast.error('Session expired. Please login again.')
+              setLoading(false)
+              return
+            }
+          }
+
           setUser(decoded)
           fetchProfile()
+        } catch (error) {
+          console.error('Invalid token', error)
+          clearTokens()
+          setUser(null)
+          toast.error('Invalid session. Please login again.')
         }
-      } catch (error) {
-        console.error('Invalid token', error)
-        localStorage.removeItem('token')
-        setUser(null)
-        toast.error('Invalid session. Please login again.')
       }
+
+      setLoading(false)
     }
-    
-    setLoading(false)
+
+    restoreSession()
   }, [])
 
+  // Refresh the access token shortly before it expires
+  useEffect(() => {
+    if (!user) {
+      return
+    }
+    const token = localStorage.getItem('token')
+    if (!token) {
+      return
+    }
+
+    let timer
+    try {
+      const { exp } = jwtDecode(token)
+      const delay = Math.max(exp * 1000 - Date.now() - 60 * 1000, 0)
+      timer = setTimeout(async () => {
+        if (await refreshSession()) {
+          setUser((current) => ({ ...current }))
+        } else {
+          setUser(null)
+          toast.error('Session expired. Please login again.')
+        }
+      }, delay)
+    } catch (error) {
+      console.error('Invalid token', error)
+    }
+
+    return () => clearTimeout(timer)
+  }, [user])
+
   const login = async (credentials) => {
     try {
       const response = await authService.login(credentials)
+      // 2FA: the caller has to finish with completeTwoFactorLogin
+      if (response.twoFactorRequired) {
+        return {
+          twoFactorRequired: true,
+          twoFactorTicket: response.twoFactorTicket,
+          email: credentials.email,
+          rememberMe: credentials.rememberMe,
+        }
+      }
       if (response.token) {
-        localStorage.setItem('token', response.token)
+        saveTokens(response)
         setUser({ ...response.user, role: response.user.role })
         await fetchProfile()
         toast.success('เข้าสู่ระบบสำเร็จ')
@@ -67,12 +148,26 @@ export const AuthProvider = ({ children }) => {
     }
   }
 
+  const completeTwoFactorLogin = async ({ email, twoFactorTicket, code, rememberMe }) => {
+    try {
+      const response = await authService.loginTwoFactor({ email, twoFactorTicket, code, rememberMe })
+      saveTokens(response)
+      setUser({ ...response.user, role: response.user.role })
+      await fetchProfile()
+      toast.success('เข้าสู่ระบบสำเร็จ')
+      return response.user
+    } catch (error) {
+      toast.error(error.message || 'รหัสยืนยันตัวตนไม่ถูกต้อง')
+      return false
+    }
+  }
+
   const register = async (userData) => {
     try {
       const response = await authService.register(userData)
       
       if (response.success) {
-        toast.success('สร้างบัญชีสำเร็จ')
+        toast.success('สร้างบัญชีสำเร็จ กรุณายืนยันอีเมลด้วยรหัส OTP')
         return true
       }
       
@@ -83,8 +178,16 @@ export const AuthProvider = ({ children }) => {
     }
   }
 
-  const logout = () => {
-    localStorage.removeItem('token')
+  const logout = async () => {
+    const refreshToken = localStorage.getItem('refreshToken')
+    if (refreshToken) {
+      try {
+        await authService.logout(refreshToken)
+      } catch (error) {
+        console.error('Logout error:', error)
+      }
+    }
+    clearTokens()
     setUser(null)
     toast.success('ออกจากระบบเรียบร้อย')
   }
@@ -94,6 +197,7 @@ export const AuthProvider = ({ children }) => {
     setUser,
     loading,
     login,
+    completeTwoFactorLogin,
     register,
     logout,
     isAuthenticated: !!user,
@@ -1,11 +1,11 @@
 import { Container, Row, Col, Button } from 'react-bootstrap'
 import { Link } from 'react-router-dom'
-import { useAuth } from '../context/AuthContext'
+import { useAuth, hasPermission } from '../context/AuthContext'
 import '../styles/NotFoundPage.css'
 
 const NotFoundPage = () => {
   const { user, isAuthenticated } = useAuth();
-  const homeLink = isAuthenticated && user.role === 'admin' ? '/admin/manage' : '/';
+  const homeLink = isAuthenticated && hasPermission(user, 'admin:panel') ? '/admin/manage' : '/';
   return (
     <div className="not-found-container fade-in">
       <Container>
@@ -3,6 +3,7 @@ import '../styles/ProfilePage.css'
 import { useNavigate } from 'react-router-dom'
 import { useAuth } from '../context/AuthContext'
 import authService from '../services/authService'
+import NotificationPreferences from '../components/common/NotificationPreferences'
 import { toast } from 'react-toastify'
 
 function formatDate(dateString) {
@@ -151,6 +152,7 @@ const ProfilePage = () => {
                 <label className="form-label">สร้างบัญชีเมื่อ</label>
                 <input type="text" value={memberSince} readOnly />
               </div>
+              <NotificationPreferences />
               <div className="profile-actions">
                 <button type="button" className="change-password-button" onClick={handleChangePassword} disabled={loading}>
                   เปลี่ยนรหัสผ่าน
@@ -11,7 +11,7 @@ const ResetPassword = () => {
   const location = useLocation()
   const navigate = useNavigate()
   const email = location.state?.email || ''
-  const otp = location.state?.otp || ''
+  const resetTicket = location.state?.resetTicket || ''
   const [newPassword, setNewPassword] = useState('')
   const [confirmPassword, setConfirmPassword] = useState('')
   const [isSubmitting, setIsSubmitting] = useState(false)
@@ -19,10 +19,10 @@ const ResetPassword = () => {
   const [showConfirmPassword, setShowConfirmPassword] = useState(false)
 
   useEffect(() => {
-    if (!email || !otp) {
+    if (!email || !resetTicket) {
       navigate('/forgot-password')
     }
-  }, [email, otp, navigate])
+  }, [email, resetTicket, navigate])
 
   const handleSubmit = async (e) => {
     e.preventDefault()
@@ -36,7 +36,7 @@ const ResetPassword = () => {
     }
     setIsSubmitting(true)
     try {
-      await authService.resetPassword(email, otp, newPassword)
+      await authService.resetPassword(email, resetTicket, newPassword)
       toast.success('เปลี่ยนรหัสผ่านสำเร็จ!')
       navigate('/login')
     } catch (err) {
@@ -0,0 +1,88 @@
+import { useState } from 'react'
+import { useLocation, useNavigate } from 'react-router-dom'
+import { toast } from 'react-toastify'
+import authService from '../services/authService'
+import OTPInput from '../components/common/OTPInput'
+import '../styles/ForgotPasswordPage.css'
+
+const VerifyEmailPage = () => {
+  const location = useLocation()
+  const navigate = useNavigate()
+  const [email, setEmail] = useState(location.state?.email || '')
+  const [otp, setOtp] = useState('')
+  const [isSubmitting, setIsSubmitting] = useState(false)
+  const [isResending, setIsResending] = useState(false)
+
+  const handleResend = async () => {
+    if (!email) {
+      toast.error('กรุณากรอกอีเมล')
+      return
+    }
+    setIsResending(true)
+    try {
+      await authService.resendVerification(email)
+      toast.success('ส่งรหัส OTP ใหม่ไปที่อีเมลของคุณแล้ว')
+    } catch (err) {
+      toast.error(err.message || 'ไม่สามารถส่งรหัส OTP ได้')
+    } finally {
+      setIsResending(false)
+    }
+  }
+
+  const handleSubmit = async (e) => {
+    e.preventDefault()
+    setIsSubmitting(true)
+    try {
+      await authService.verifyEmail(email, otp)
+      toast.success('ยืนยันอีเมลสำเร็จ! กรุณาเข้าสู่ระบบ')
+      navigate('/login')
+    } catch (err) {
+      toast.error(err.message || 'รหัส OTP ไม่ถูกต้อง')
+    } finally {
+      setIsSubmitting(false)
+    }
+  }
+
+  return (
+    <div className="forgot-container">
+      <div className="forgot-card">
+        <div className="forgot-form">
+          <form onSubmit={handleSubmit} autoComplete="on">
+            <h2 className="forgot-title">ยืนยันอีเมล</h2>
+            <div className="form-group">
+              <label className="form-label">อีเมล</label>
+              <input
+                type="email"
+                placeholder="กรุณากรอกอีเมลของคุณ"
+                autoComplete="email"
+                value={email}
+                onChange={e => setEmail(e.target.value)}
+              />
+            </div>
+            <div className="form-group" style={{ display: 'flex', alignItems: 'center', gap: '1rem' }}>
+              <label className="form-label" style={{ marginBottom: 0, minWidth: 40 }}>OTP</label>
+              <OTPInput value={otp} onChange={setOtp} length={6} />
+            </div>
+            <button
+              type="submit"
+              className="forgot-button"
+              disabled={isSubmitting || !email || otp.length !== 6}
+            >
+              {isSubmitting ? 'กำลังดำเนินการ...' : 'ยืนยันอีเมล'}
+            </button>
+            <div className="text-center mt-3">
+              <button type="button" className="btn btn-link forgot-link" onClick={handleResend} disabled={isResending}>
+                {isResending ? 'กำลังส่ง...' : 'ส่งรหัส OTP อีกครั้ง'}
+              </button>
+            </div>
+            <div className="text-center mt-3">
+              <a href="/login" className="forgot-link">กลับไปยังหน้าเข้าสู่ระบบ</a>
+            </div>
+          </form>
+        </div>
+      </div>
+    </div>
+  )
+}
+
+export default VerifyEmailPage
@@ -34,6 +34,45 @@ const login = async (credentials) => {
   }
 }
 
+const loginTwoFactor = async (payload) => {
+  try {
+    const response = await api.post('/auth/login/2fa', payload)
+    return response.data
+  } catch (error) {
+    console.error('Two-factor login error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Invalid verification code.')
+    }
+    throw error
+  }
+}
+
+const refresh = async (refreshToken) => {
+  try {
+    const response = await api.post('/auth/refresh', { refreshToken })
+    return response.data
+  } catch (error) {
+    console.error('Refresh token error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Session expired. Please login again.')
+    }
+    throw error
+  }
+}
+
+const logout = async (refreshToken) => {
+  try {
+    const response = await api.post('/auth/logout', { refreshToken })
+    return response.data
+  } catch (error) {
+    console.error('Logout error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to logout.')
+    }
+    throw error
+  }
+}
+
 const register = async (userData) => {
   try {
     const response = await api.post('/auth/register', userData)
@@ -50,6 +89,32 @@ const register = async (userData) => {
   }
 }
 
+const verifyEmail = async (email, otp) => {
+  try {
+    const response = await api.post('/auth/verify-email', { email, otp })
+    return response.data
+  } catch (error) {
+    console.error('Verify email error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to verify email.')
+    }
+    throw error
+  }
+}
+
+const resendVerification = async (email) => {
+  try {
+    const response = await api.post('/auth/resend-verification', { email })
+    return response.data
+  } catch (error) {
+    console.error('Resend verification error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to resend verification email.')
+    }
+    throw error
+  }
+}
+
 const getProfile = async () => {
   try {
     const response = await api.get('/users/me')
@@ -82,6 +147,11 @@ const changePassword = async (currentPassword, newPassword) => {
       currentPassword,
       newPassword
     })
+    // Other sessions are logged out; this one gets a fresh token pair
+    if (response.data.token) {
+      localStorage.setItem('token', response.data.token)
+      localStorage.setItem('refreshToken', response.data.refreshToken)
+    }
     return response.data
   } catch (error) {
     console.error('Change password error:', error)
@@ -107,6 +177,45 @@ const deleteAccount = async (currentPassword) => {
   }
 }
 
+const getSessions = async () => {
+  try {
+    const response = await api.get('/users/me/sessions')
+    return response.data.sessions
+  } catch (error) {
+    console.error('Get sessions error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to fetch sessions.')
+    }
+    throw error
+  }
+}
+
+const revokeSession = async (id) => {
+  try {
+    const response = await api.delete(`/users/me/sessions/${id}`)
+    return response.data
+  } catch (error) {
+    console.error('Revoke session error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to revoke session.')
+    }
+    throw error
+  }
+}
+
+const revokeOtherSessions = async () => {
+  try {
+    const response = await api.delete('/users/me/sessions')
+    return response.data
+  } catch (error) {
+    console.error('Revoke other sessions error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to revoke sessions.')
+    }
+    throw error
+  }
+}
+
 // Add token to axios requests
 api.interceptors.request.use(
   config => {
@@ -150,9 +259,9 @@ const verifyOtp = async (email, otp) => {
   }
 }
 
-const resetPassword = async (email, otp, newPassword) => {
+const resetPassword = async (email, resetTicket, newPassword) => {
   try {
-    const response = await api.post('/auth/reset-password', { email, otp, newPassword })
+    const response = await api.post('/auth/reset-password', { email, resetTicket, newPassword })
     return response.data
   } catch (error) {
     console.error('Password reset error:', error)
@@ -163,14 +272,107 @@ const resetPassword = async (email, otp, newPassword) => {
   }
 }
 
+const getPreferences = async () => {
+  try {
+    const response = await api.get('/users/me/preferences')
+    return response.data
+  } catch (error) {
+    console.error('Get preferences error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to fetch preferences.')
+    }
+    throw error
+  }
+}
+
+// Only the fields in changes are updated
+const updatePreferences = async (changes) => {
+  try {
+    const response = await api.patch('/users/me/preferences', changes)
+    return response.data
+  } catch (error) {
+    console.error('Update preferences error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to update preferences.')
+    }
+    throw error
+  }
+}
+
+const createTelegramLink = async () => {
+  try {
+    const response = await api.post('/users/me/telegram/link')
+    return response.data
+  } catch (error) {
+    console.error('Telegram link error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to create Telegram link.')
+    }
+    throw error
+  }
+}
+
+const unlinkTelegram = async () => {
+  try {
+    const response = await api.delete('/users/me/telegram')
+    return response.data
+  } catch (error) {
+    console.error('Telegram unlink error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to unlink Telegram.')
+    }
+    throw error
+  }
+}
+
+const createLineLink = async () => {
+  try {
+    const response = await api.post('/users/me/line/link')
+    return response.data
+  } catch (error) {
+    console.error('LINE link error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to create LINE link.')
+    }
+    throw error
+  }
+}
+
+const unlinkLine = async () => {
+  try {
+    const response = await api.delete('/users/me/line')
+    return response.data
+  } catch (error) {
+    console.error('LINE unlink error:', error)
+    if (error.response) {
+      throw new Error(error.response.data.error || 'Failed to unlink LINE.')
+    }
+    throw error
+  }
+}
+
 export default {
   login,
+  loginTwoFactor,
+  refresh,
+  logout,
   register,
+  verifyEmail,
+  resendVerification,
   getProfile,
   updateProfile,
   changePassword,
   deleteAccount,
+  getSessions,
+  revokeSession,
+  revokeOtherSessions,
   requestPasswordReset,
   verifyOtp,
-  resetPassword
+  resetPassword,
+  getPreferences,
+  updatePreferences,
+  createTelegramLink,
+  unlinkTelegram,
+  createLineLink,
+  unlinkLine
 }
\ No newline at end of file
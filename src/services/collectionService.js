@@ -18,8 +18,17 @@ api.interceptors.request.use(
 )
 
 export const getCollection = async () => {
-  const res = await api.get('/collection')
-  return res.data.collection
+  // The API is paginated; follow nextCursor until every page is loaded
+  const items = []
+  let cursor = ''
+  do {
+    const res = await api.get('/collection', {
+      params: { limit: 200, ...(cursor ? { cursor } : {}) }
+    })
+    items.push(...(res.data.collection || []))
+    cursor = res.data.nextCursor
+  } while (cursor)
+  return items
 }
 
 export const addCollection = async (data) => {